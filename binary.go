@@ -0,0 +1,876 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// Compact binary encoding of the crdts. An encoding is
+//
+//	version byte | type tag byte | actors | state
+//
+// where actors is the dictionary of every actor of the state, as a
+// count followed by the actors, and the state refers to an actor by
+// its index in the dictionary. Counts, counters, indexes and lengths
+// are uvarints, timestamps of dots are uvarints and timestamps of lww
+// writes are varints. Strings and byte slices are a length followed by
+// the bytes, and a dot is an actor, a counter and a timestamp.
+//
+// Elements, values and actors of the generic types are encoded as
+// strings, byte slices or varints when they are one, with MarshalBinary
+// when they implement encoding.BinaryMarshaler, and as JSON otherwise.
+// The embedded crdts of a map share the dictionary of the map.
+//
+// The state is laid out like the JSON encoding. Decoding fails on a
+// version it does not know, so a new version must be added alongside
+// version 1, which stays decodable.
+
+package dt
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// The version of the format written by MarshalBinary
+const BinaryVersion = 1
+
+// Type tags of the format. They are stable, new types get new tags.
+const (
+	binaryDVV byte = iota + 1
+	binaryDVVSet
+	binaryGCounter
+	binaryPNCounter
+	binaryEMCounter
+	binaryGSet
+	binaryTwoPSet
+	binaryLWWReg
+	binaryMVRegister
+	binaryORSet
+	binaryRWSet
+	binaryORSWOT
+	binaryLWWElementSet
+	binaryEWFlag
+	binaryDWFlag
+	binaryMap
+)
+
+func (v DVV) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryDVV, v.encodeBinary)
+}
+
+func (v *DVV) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryDVV, v.decodeBinary)
+}
+
+func (c DVVSet) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryDVVSet, c.encodeBinary)
+}
+
+func (c *DVVSet) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryDVVSet, c.decodeBinary)
+}
+
+func (counter GCounterOf[A]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryGCounter, counter.encodeBinary)
+}
+
+func (counter *GCounterOf[A]) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryGCounter, counter.decodeBinary)
+}
+
+func (counter PNCounterOf[A]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryPNCounter, counter.encodeBinary)
+}
+
+func (counter *PNCounterOf[A]) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryPNCounter, counter.decodeBinary)
+}
+
+func (counter EMCounter) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryEMCounter, counter.encodeBinary)
+}
+
+func (counter *EMCounter) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryEMCounter, counter.decodeBinary)
+}
+
+func (gset GSetOf[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryGSet, gset.encodeBinary)
+}
+
+func (gset *GSetOf[T]) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryGSet, gset.decodeBinary)
+}
+
+func (twopset TwoPSetOf[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryTwoPSet, twopset.encodeBinary)
+}
+
+func (twopset *TwoPSetOf[T]) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryTwoPSet, twopset.decodeBinary)
+}
+
+func (reg LWWRegOf[V]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryLWWReg, reg.encodeBinary)
+}
+
+func (reg *LWWRegOf[V]) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryLWWReg, reg.decodeBinary)
+}
+
+func (reg MVRegister) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryMVRegister, reg.encodeBinary)
+}
+
+func (reg *MVRegister) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryMVRegister, reg.decodeBinary)
+}
+
+func (orset ORSetOf[T]) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryORSet, orset.encodeBinary)
+}
+
+func (orset *ORSetOf[T]) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryORSet, orset.decodeBinary)
+}
+
+func (rwset RWSet) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryRWSet, rwset.encodeBinary)
+}
+
+func (rwset *RWSet) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryRWSet, rwset.decodeBinary)
+}
+
+func (orswot ORSWOT) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryORSWOT, orswot.encodeBinary)
+}
+
+func (orswot *ORSWOT) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryORSWOT, orswot.decodeBinary)
+}
+
+func (lwwset LWWElementSet) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryLWWElementSet, lwwset.encodeBinary)
+}
+
+func (lwwset *LWWElementSet) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryLWWElementSet, lwwset.decodeBinary)
+}
+
+func (flag EWFlag) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryEWFlag, flag.encodeBinary)
+}
+
+func (flag *EWFlag) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryEWFlag, flag.decodeBinary)
+}
+
+func (flag DWFlag) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryDWFlag, flag.encodeBinary)
+}
+
+func (flag *DWFlag) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryDWFlag, flag.decodeBinary)
+}
+
+func (m Map) MarshalBinary() ([]byte, error) {
+	return marshalBinary(binaryMap, m.encodeBinary)
+}
+
+func (m *Map) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, binaryMap, m.decodeBinary)
+}
+
+// ------------------- private functions -------------------
+
+// Writes the state, collecting the actors it refers to
+type binaryWriter struct {
+	actors map[string]uint64
+	dict   []string
+	body   []byte
+	err    error
+}
+
+// Reads the state, keeping the first error. Reads after an error
+// return zero values.
+type binaryReader struct {
+	data   []byte
+	actors []string
+	err    error
+}
+
+// The embedded crdts of a map are written with the writer of the map
+type binaryCodec interface {
+	encodeBinary(w *binaryWriter)
+	decodeBinary(r *binaryReader)
+}
+
+func marshalBinary(tag byte, encode func(w *binaryWriter)) ([]byte, error) {
+	w := &binaryWriter{actors: map[string]uint64{}}
+	encode(w)
+	if w.err != nil {
+		return nil, w.err
+	}
+	head := &binaryWriter{body: []byte{BinaryVersion, tag}}
+	head.uvarint(uint64(len(w.dict)))
+	for _, actor := range w.dict {
+		head.writeString(actor)
+	}
+	return append(head.body, w.body...), nil
+}
+
+func unmarshalBinary(data []byte, tag byte, decode func(r *binaryReader)) error {
+	if len(data) < 2 {
+		return xerrors.New("binary encoding is too short")
+	}
+	if data[0] != BinaryVersion {
+		return xerrors.Errorf("unsupported version %d of binary encoding", data[0])
+	}
+	if data[1] != tag {
+		return xerrors.Errorf("expected type tag %d but got %d", tag, data[1])
+	}
+	r := &binaryReader{data: data[2:]}
+	for n := r.readCount(); n > 0; n-- {
+		r.actors = append(r.actors, r.readString())
+	}
+	if r.err != nil {
+		return r.err
+	}
+	decode(r)
+	if r.err == nil && len(r.data) > 0 {
+		r.fail(xerrors.Errorf("%d trailing bytes after binary encoding", len(r.data)))
+	}
+	return r.err
+}
+
+func (w *binaryWriter) fail(err error) {
+	if w.err == nil && err != nil {
+		w.err = err
+	}
+}
+
+func (w *binaryWriter) writeByte(b byte) {
+	w.body = append(w.body, b)
+}
+
+func (w *binaryWriter) uvarint(x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.body = append(w.body, buf[:binary.PutUvarint(buf[:], x)]...)
+}
+
+func (w *binaryWriter) varint(x int64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.body = append(w.body, buf[:binary.PutVarint(buf[:], x)]...)
+}
+
+func (w *binaryWriter) writeBytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.body = append(w.body, b...)
+}
+
+func (w *binaryWriter) writeString(s string) {
+	w.uvarint(uint64(len(s)))
+	w.body = append(w.body, s...)
+}
+
+// Write the index of the actor, adding it to the dictionary
+func (w *binaryWriter) writeActor(actor string) {
+	idx, ok := w.actors[actor]
+	if !ok {
+		idx = uint64(len(w.dict))
+		w.actors[actor] = idx
+		w.dict = append(w.dict, actor)
+	}
+	w.uvarint(idx)
+}
+
+func (w *binaryWriter) writeDot(dot Dot) {
+	w.writeActor(dot.node)
+	w.uvarint(uint64(dot.counter))
+	w.uvarint(uint64(dot.timestamp))
+}
+
+func (w *binaryWriter) writeDots(v DVV) {
+	w.uvarint(uint64(v.Len()))
+	for _, dot := range v.vector {
+		w.writeDot(dot)
+	}
+}
+
+func (w *binaryWriter) writeStrings(values []string) {
+	w.uvarint(uint64(len(values)))
+	for _, value := range values {
+		w.writeString(value)
+	}
+}
+
+func (r *binaryReader) fail(err error) {
+	if r.err == nil && err != nil {
+		r.err = err
+		r.data = nil
+	}
+}
+
+func (r *binaryReader) readByte() byte {
+	if len(r.data) < 1 {
+		r.fail(xerrors.New("unexpected end of binary encoding"))
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	x, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail(xerrors.New("invalid uvarint in binary encoding"))
+		return 0
+	}
+	r.data = r.data[n:]
+	return x
+}
+
+func (r *binaryReader) varint() int64 {
+	x, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail(xerrors.New("invalid varint in binary encoding"))
+		return 0
+	}
+	r.data = r.data[n:]
+	return x
+}
+
+func (r *binaryReader) readUint32() uint32 {
+	x := r.uvarint()
+	if x > 0xffffffff {
+		r.fail(xerrors.Errorf("%d overflows uint32", x))
+		return 0
+	}
+	return uint32(x)
+}
+
+// Read a count of items, each of which takes at least a byte
+func (r *binaryReader) readCount() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail(xerrors.Errorf("count %d exceeds the binary encoding", n))
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) readBytes() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.fail(xerrors.New("unexpected end of binary encoding"))
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *binaryReader) readString() string {
+	return string(r.readBytes())
+}
+
+func (r *binaryReader) readActor() string {
+	idx := r.uvarint()
+	if idx >= uint64(len(r.actors)) {
+		r.fail(xerrors.Errorf("actor %d is not in the dictionary", idx))
+		return ""
+	}
+	return r.actors[idx]
+}
+
+func (r *binaryReader) readDot() Dot {
+	return Dot{node: r.readActor(), counter: r.readUint32(), timestamp: r.readUint32()}
+}
+
+// Read the dots into the vclock, keeping its clock
+func (r *binaryReader) readDots(v *DVV) {
+	acc := DVV{clock: v.clock}
+	for n := r.readCount(); n > 0; n-- {
+		acc.vector = append(acc.vector, r.readDot())
+	}
+	if r.err != nil {
+		return
+	}
+	if err := acc.Validate(); err != nil {
+		r.fail(err)
+		return
+	}
+	*v = acc
+}
+
+func (r *binaryReader) readStrings() []string {
+	var acc []string
+	for n := r.readCount(); n > 0; n-- {
+		acc = append(acc, r.readString())
+	}
+	return acc
+}
+
+// Write a value of any type
+func writeValue[V any](w *binaryWriter, value V) {
+	switch value := any(value).(type) {
+	case string:
+		w.writeString(value)
+	case []byte:
+		w.writeBytes(value)
+	case bool:
+		if value {
+			w.writeByte(1)
+		} else {
+			w.writeByte(0)
+		}
+	case int:
+		w.varint(int64(value))
+	case int32:
+		w.varint(int64(value))
+	case int64:
+		w.varint(value)
+	case uint:
+		w.uvarint(uint64(value))
+	case uint32:
+		w.uvarint(uint64(value))
+	case uint64:
+		w.uvarint(value)
+	case encoding.BinaryMarshaler:
+		data, err := value.MarshalBinary()
+		if err != nil {
+			w.fail(err)
+		}
+		w.writeBytes(data)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			w.fail(err)
+		}
+		w.writeBytes(data)
+	}
+}
+
+// Read a value written by writeValue
+func readValue[V any](r *binaryReader) V {
+	var acc V
+	switch value := any(&acc).(type) {
+	case *string:
+		*value = r.readString()
+	case *[]byte:
+		*value = append([]byte{}, r.readBytes()...)
+	case *bool:
+		*value = r.readByte() != 0
+	case *int:
+		*value = int(r.varint())
+	case *int32:
+		*value = int32(r.varint())
+	case *int64:
+		*value = r.varint()
+	case *uint:
+		*value = uint(r.uvarint())
+	case *uint32:
+		*value = r.readUint32()
+	case *uint64:
+		*value = r.uvarint()
+	case encoding.BinaryUnmarshaler:
+		if err := value.UnmarshalBinary(r.readBytes()); err != nil {
+			r.fail(err)
+		}
+	default:
+		if data := r.readBytes(); r.err == nil {
+			if err := json.Unmarshal(data, value); err != nil {
+				r.fail(err)
+			}
+		}
+	}
+	return acc
+}
+
+// Write an actor of any type. Actors which are not strings are
+// added to the dictionary by their encoding.
+func writeActorOf[A comparable](w *binaryWriter, actor A) {
+	if actor, ok := any(actor).(string); ok {
+		w.writeActor(actor)
+		return
+	}
+	value := &binaryWriter{}
+	writeValue(value, actor)
+	w.fail(value.err)
+	w.writeActor(string(value.body))
+}
+
+func readActorOf[A comparable](r *binaryReader) A {
+	actor := r.readActor()
+	if actor, ok := any(actor).(A); ok {
+		return actor
+	}
+	value := &binaryReader{data: []byte(actor)}
+	acc := readValue[A](value)
+	r.fail(value.err)
+	return acc
+}
+
+func (v DVV) encodeBinary(w *binaryWriter) {
+	w.writeDots(v)
+}
+
+func (v *DVV) decodeBinary(r *binaryReader) {
+	r.readDots(v)
+}
+
+func (c DVVSet) encodeBinary(w *binaryWriter) {
+	w.uvarint(uint64(len(c.entries)))
+	for _, e := range c.entries {
+		w.writeActor(e.node)
+		w.uvarint(uint64(e.counter))
+		w.writeStrings(e.values)
+	}
+	w.writeStrings(c.anonymous)
+}
+
+func (c *DVVSet) decodeBinary(r *binaryReader) {
+	acc := DVVSet{}
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		e := dvvEntry{node: r.readActor(), counter: r.readUint32(), values: r.readStrings()}
+		if last := len(acc.entries) - 1; last >= 0 && acc.entries[last].node >= e.node {
+			r.fail(xerrors.Errorf("dvvset is not sorted by node at %q", e.node))
+		} else if uint32(len(e.values)) > e.counter {
+			r.fail(xerrors.Errorf("dvvset has more values than events at %q", e.node))
+		}
+		acc.entries = append(acc.entries, e)
+	}
+	acc.anonymous = r.readStrings()
+	if r.err == nil {
+		*c = acc
+	}
+}
+
+func (counter GCounterOf[A]) encodeBinary(w *binaryWriter) {
+	w.uvarint(uint64(len(counter.Counters)))
+	for _, actor := range sortedKeys(counter.Counters) {
+		writeActorOf(w, actor)
+		w.uvarint(uint64(counter.Counters[actor]))
+	}
+}
+
+func (counter *GCounterOf[A]) decodeBinary(r *binaryReader) {
+	acc := NewGCounterOf[A]()
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		actor := readActorOf[A](r)
+		if _, ok := acc.Counters[actor]; ok {
+			r.fail(xerrors.Errorf("gcounter has duplicated actor %v", actor))
+		}
+		acc.Counters[actor] = uint(r.uvarint())
+	}
+	if r.err == nil {
+		*counter = acc
+	}
+}
+
+func (counter PNCounterOf[A]) encodeBinary(w *binaryWriter) {
+	counter.P.encodeBinary(w)
+	counter.N.encodeBinary(w)
+}
+
+func (counter *PNCounterOf[A]) decodeBinary(r *binaryReader) {
+	acc := NewPNCounterOf[A]()
+	acc.P.decodeBinary(r)
+	acc.N.decodeBinary(r)
+	if r.err == nil {
+		*counter = acc
+	}
+}
+
+func (counter EMCounter) encodeBinary(w *binaryWriter) {
+	w.writeDots(counter.Clock)
+	w.uvarint(uint64(len(counter.Entries)))
+	for _, actor := range sortedKeys(counter.Entries) {
+		entry := counter.Entries[actor]
+		w.writeActor(actor)
+		w.uvarint(uint64(entry.Counter))
+		w.uvarint(uint64(entry.P))
+		w.uvarint(uint64(entry.N))
+	}
+}
+
+func (counter *EMCounter) decodeBinary(r *binaryReader) {
+	acc := EMCounter{Clock: counter.Clock, Entries: map[string]EMEntry{}}
+	r.readDots(&acc.Clock)
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		actor := r.readActor()
+		if _, ok := acc.Entries[actor]; ok {
+			r.fail(xerrors.Errorf("emcounter has duplicated actor %q", actor))
+		}
+		acc.Entries[actor] = EMEntry{Counter: r.readUint32(), P: uint(r.uvarint()), N: uint(r.uvarint())}
+	}
+	if r.err == nil {
+		*counter = acc
+	}
+}
+
+func (gset GSetOf[T]) encodeBinary(w *binaryWriter) {
+	elems := gset.Values()
+	w.uvarint(uint64(len(elems)))
+	for _, elem := range elems {
+		writeValue(w, elem)
+	}
+}
+
+func (gset *GSetOf[T]) decodeBinary(r *binaryReader) {
+	acc := NewGSetOf[T]()
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		acc.Add(readValue[T](r))
+	}
+	if r.err == nil {
+		*gset = acc
+	}
+}
+
+func (twopset TwoPSetOf[T]) encodeBinary(w *binaryWriter) {
+	twopset.Adds.encodeBinary(w)
+	twopset.Removes.encodeBinary(w)
+}
+
+func (twopset *TwoPSetOf[T]) decodeBinary(r *binaryReader) {
+	acc := NewTwoPSetOf[T]()
+	acc.Adds.decodeBinary(r)
+	acc.Removes.decodeBinary(r)
+	if r.err == nil {
+		*twopset = acc
+	}
+}
+
+func (reg LWWRegOf[V]) encodeBinary(w *binaryWriter) {
+	writeValue(w, reg.Value)
+	w.varint(reg.Timestamp)
+	w.writeActor(reg.Actor)
+}
+
+func (reg *LWWRegOf[V]) decodeBinary(r *binaryReader) {
+	acc := LWWRegOf[V]{clock: reg.clock}
+	acc.Value = readValue[V](r)
+	acc.Timestamp = r.varint()
+	acc.Actor = r.readActor()
+	if r.err == nil {
+		*reg = acc
+	}
+}
+
+func (reg MVRegister) encodeBinary(w *binaryWriter) {
+	w.uvarint(uint64(len(reg.Entries)))
+	for _, entry := range reg.sortedEntries() {
+		w.writeDot(entry.Dot)
+		w.writeDots(entry.Context)
+		w.writeString(entry.Value)
+	}
+}
+
+func (reg *MVRegister) decodeBinary(r *binaryReader) {
+	acc := MVRegister{clock: reg.clock}
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		entry := MVEntry{Context: NewDVV()}
+		entry.Dot = r.readDot()
+		r.readDots(&entry.Context)
+		entry.Value = r.readString()
+		acc.Entries = append(acc.Entries, entry)
+	}
+	if r.err == nil {
+		*reg = acc
+	}
+}
+
+func writeTokenSet[T comparable](w *binaryWriter, set map[T]Tokens) {
+	w.uvarint(uint64(len(set)))
+	for _, elem := range sortedKeys(set) {
+		writeValue(w, elem)
+		tags := set[elem].sorted()
+		w.uvarint(uint64(len(tags)))
+		for _, tag := range tags {
+			w.writeDot(tag)
+			writeValue(w, set[elem][tag])
+		}
+	}
+}
+
+func readTokenSet[T comparable](r *binaryReader) map[T]Tokens {
+	acc := map[T]Tokens{}
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		elem := readValue[T](r)
+		if _, ok := acc[elem]; ok {
+			r.fail(xerrors.Errorf("set has duplicated element %v", elem))
+		}
+		tokens := Tokens{}
+		for m := r.readCount(); m > 0 && r.err == nil; m-- {
+			tag := r.readDot()
+			tokens[tag] = readValue[bool](r)
+		}
+		acc[elem] = tokens
+	}
+	return acc
+}
+
+func (orset ORSetOf[T]) encodeBinary(w *binaryWriter) {
+	w.writeDots(orset.Clock)
+	writeTokenSet(w, orset.Set)
+}
+
+func (orset *ORSetOf[T]) decodeBinary(r *binaryReader) {
+	acc := ORSetOf[T]{Clock: orset.Clock}
+	r.readDots(&acc.Clock)
+	acc.Set = readTokenSet[T](r)
+	if r.err == nil {
+		*orset = acc
+	}
+}
+
+func (rwset RWSet) encodeBinary(w *binaryWriter) {
+	w.writeDots(rwset.Clock)
+	writeTokenSet(w, rwset.Adds)
+	writeTokenSet(w, rwset.Removes)
+}
+
+func (rwset *RWSet) decodeBinary(r *binaryReader) {
+	acc := RWSet{Clock: rwset.Clock}
+	r.readDots(&acc.Clock)
+	acc.Adds = readTokenSet[string](r)
+	acc.Removes = readTokenSet[string](r)
+	if r.err == nil {
+		*rwset = acc
+	}
+}
+
+func (orswot ORSWOT) encodeBinary(w *binaryWriter) {
+	w.writeDots(orswot.Clock)
+	elems := orswot.Value()
+	w.uvarint(uint64(len(elems)))
+	for _, elem := range elems {
+		w.writeString(elem)
+		w.writeDots(orswot.Entries[elem])
+	}
+}
+
+func (orswot *ORSWOT) decodeBinary(r *binaryReader) {
+	acc := ORSWOT{Clock: orswot.Clock, Entries: map[string]DVV{}}
+	r.readDots(&acc.Clock)
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		elem := r.readString()
+		if _, ok := acc.Entries[elem]; ok {
+			r.fail(xerrors.Errorf("orswot has duplicated element %q", elem))
+		}
+		dots := NewDVV()
+		r.readDots(&dots)
+		acc.Entries[elem] = dots
+	}
+	if r.err == nil {
+		*orswot = acc
+	}
+}
+
+func writeStamps(w *binaryWriter, stamps map[string]LWWStamp) {
+	w.uvarint(uint64(len(stamps)))
+	for _, elem := range sortedKeys(stamps) {
+		w.writeString(elem)
+		w.varint(stamps[elem].Timestamp)
+		w.writeActor(stamps[elem].Actor)
+	}
+}
+
+func readStamps(r *binaryReader) map[string]LWWStamp {
+	acc := map[string]LWWStamp{}
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		elem := r.readString()
+		if _, ok := acc[elem]; ok {
+			r.fail(xerrors.Errorf("lwwelementset has duplicated element %q", elem))
+		}
+		acc[elem] = LWWStamp{Timestamp: r.varint(), Actor: r.readActor()}
+	}
+	return acc
+}
+
+func (lwwset LWWElementSet) encodeBinary(w *binaryWriter) {
+	if _, ok := biasNames[lwwset.Bias]; !ok {
+		w.fail(xerrors.Errorf("lwwelementset has unknown bias %d", lwwset.Bias))
+	}
+	w.writeByte(byte(lwwset.Bias))
+	writeStamps(w, lwwset.Adds)
+	writeStamps(w, lwwset.Removes)
+}
+
+func (lwwset *LWWElementSet) decodeBinary(r *binaryReader) {
+	acc := LWWElementSet{Bias: Bias(r.readByte()), clock: lwwset.clock}
+	if _, ok := biasNames[acc.Bias]; !ok {
+		r.fail(xerrors.Errorf("lwwelementset has unknown bias %d", acc.Bias))
+	}
+	acc.Adds = readStamps(r)
+	acc.Removes = readStamps(r)
+	if r.err == nil {
+		*lwwset = acc
+	}
+}
+
+func (flag EWFlag) encodeBinary(w *binaryWriter) {
+	w.writeDots(flag.Clock)
+	w.writeDots(flag.Dots)
+}
+
+func (flag *EWFlag) decodeBinary(r *binaryReader) {
+	acc := EWFlag{Clock: flag.Clock, Dots: NewDVV()}
+	r.readDots(&acc.Clock)
+	r.readDots(&acc.Dots)
+	if r.err == nil {
+		*flag = acc
+	}
+}
+
+func (flag DWFlag) encodeBinary(w *binaryWriter) {
+	w.writeDots(flag.Clock)
+	w.writeDots(flag.Dots)
+}
+
+func (flag *DWFlag) decodeBinary(r *binaryReader) {
+	acc := DWFlag{Clock: flag.Clock, Dots: NewDVV()}
+	r.readDots(&acc.Clock)
+	r.readDots(&acc.Dots)
+	if r.err == nil {
+		*flag = acc
+	}
+}
+
+func (m Map) encodeBinary(w *binaryWriter) {
+	w.writeDots(m.Clock)
+	fields := sortedFields(m.Entries)
+	w.uvarint(uint64(len(fields)))
+	for _, field := range fields {
+		entry := m.Entries[field]
+		w.writeString(field.Name)
+		w.writeByte(byte(field.Type))
+		w.writeDots(entry.Dots)
+		entry.Value.(binaryCodec).encodeBinary(w)
+	}
+}
+
+func (m *Map) decodeBinary(r *binaryReader) {
+	acc := Map{Clock: m.Clock, Entries: map[Field]MapEntry{}}
+	r.readDots(&acc.Clock)
+	for n := r.readCount(); n > 0 && r.err == nil; n-- {
+		field := Field{Name: r.readString(), Type: FieldType(r.readByte())}
+		if _, ok := fieldTypeNames[field.Type]; !ok {
+			r.fail(xerrors.Errorf("map has unknown field type %d", field.Type))
+			return
+		}
+		if _, ok := acc.Entries[field]; ok {
+			r.fail(xerrors.Errorf("map has duplicated field %q", field.Name))
+		}
+		dots := NewDVV()
+		r.readDots(&dots)
+		value := newEmbedded(field.Type)
+		value.(binaryCodec).decodeBinary(r)
+		acc.Entries[field] = MapEntry{Dots: dots, Value: value}
+	}
+	if r.err == nil {
+		*m = acc
+	}
+}
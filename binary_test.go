@@ -0,0 +1,135 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, replica := range replicas {
+		a := replica("a")
+		a.MergeCRDT(replica("b"))
+		name := a.TypeName()
+
+		data, err := a.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: marshal should succeed %v", name, err)
+			continue
+		}
+		decoded := reflect.New(reflect.TypeOf(a).Elem()).Interface().(CRDT)
+		if err := decoded.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+			t.Errorf("%s: unmarshal should succeed %v %x", name, err, data)
+			continue
+		}
+		if !decoded.EqualCRDT(a) {
+			t.Errorf("%s: decoded should be equal with the crdt %#v %#v", name, decoded, a)
+		}
+		again, _ := decoded.(encoding.BinaryMarshaler).MarshalBinary()
+		if string(again) != string(data) {
+			t.Errorf("%s: encoding should round trip exactly %x %x", name, data, again)
+		}
+	}
+}
+
+func TestBinarySize(t *testing.T) {
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	vclock := NewDVVWithClock(clock)
+	counter := NewGCounter()
+	orswot := NewORSWOTWithClock(clock)
+	for i := 0; i < 100; i++ {
+		actor := fmt.Sprintf("actor-%d", i)
+		vclock.Increment(actor)
+		counter.IncrementBy(actor, uint(i))
+		orswot.Add(fmt.Sprintf("elem-%d", i), "actor-0")
+	}
+
+	for _, value := range []interface{}{vclock, counter, orswot} {
+		data, _ := value.(encoding.BinaryMarshaler).MarshalBinary()
+		text, _ := json.Marshal(value)
+		if len(data)*2 > len(text) {
+			t.Errorf("binary encoding should be less than half of json %d %d", len(data), len(text))
+		}
+	}
+}
+
+func TestBinaryVersion1(t *testing.T) {
+	// version 1, dvv, one actor "a", one dot a:1 at time 5
+	data := []byte{1, 1, 1, 1, 'a', 1, 0, 1, 5}
+	vclock := NewDVV()
+	if err := vclock.UnmarshalBinary(data); err != nil {
+		t.Errorf("version 1 should be decodable %v", err)
+	}
+	if ts, _ := vclock.GetTimestamp("a"); vclock.GetCounter("a") != 1 || ts != 5 {
+		t.Errorf("vclock should be a:1 at time 5 %#v", vclock)
+	}
+
+	encoded, _ := vclock.MarshalBinary()
+	if string(encoded) != string(data) {
+		t.Errorf("vclock should be encoded as %x but %x", data, encoded)
+	}
+}
+
+func TestBinaryGeneric(t *testing.T) {
+	type point struct{ X, Y int }
+	gset := NewGSetOf[point]()
+	gset.Add(point{1, 2})
+	data, err := gset.MarshalBinary()
+	decoded := NewGSetOf[point]()
+	if err != nil || decoded.UnmarshalBinary(data) != nil || !decoded.Equal(gset) {
+		t.Errorf("decoded should be equal with the gset %#v %v", decoded, err)
+	}
+
+	counter := NewGCounterOf[int]()
+	counter.IncrementBy(-7, 3)
+	data, _ = counter.MarshalBinary()
+	decoded_counter := NewGCounterOf[int]()
+	if err := decoded_counter.UnmarshalBinary(data); err != nil || !decoded_counter.Equal(counter) {
+		t.Errorf("decoded should be equal with the counter %#v %v", decoded_counter, err)
+	}
+}
+
+func TestBinaryInvalid(t *testing.T) {
+	cases := []struct {
+		data  []byte
+		value encoding.BinaryUnmarshaler
+	}{
+		{[]byte{2, 1, 0, 0}, &DVV{}},
+		{[]byte{1, 6, 0, 0}, &DVV{}},
+		{[]byte{1, 1, 1, 1, 'a', 1, 0, 1}, &DVV{}},
+		{[]byte{1, 1, 0, 0, 0}, &DVV{}},
+		{[]byte{1, 1, 0, 1, 0, 1, 5}, &DVV{}},
+		{[]byte{1, 1, 2, 1, 'b', 1, 'a', 2, 0, 1, 5, 1, 1, 5}, &DVV{}},
+		{[]byte{1, 1, 0, 200, 1}, &DVV{}},
+	}
+	for i, c := range cases {
+		if err := c.value.UnmarshalBinary(c.data); err == nil {
+			t.Errorf("case %d: unmarshal should fail %#v", i, c.value)
+		}
+	}
+}
+
+func TestBinaryMVRegisterOrder(t *testing.T) {
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	reg_a := NewMVRegisterWithClock(clock)
+	reg_a.Assign("value1", NewDVV(), "a")
+	reg_b := NewMVRegisterWithClock(clock)
+	reg_b.Assign("value2", NewDVV(), "b")
+
+	merged1 := reg_a.Clone()
+	merged1.Merge(reg_b)
+	merged2 := reg_b.Clone()
+	merged2.Merge(reg_a)
+
+	data1, _ := merged1.MarshalBinary()
+	data2, _ := merged2.MarshalBinary()
+	if !merged1.Equal(merged2) || string(data1) != string(data2) {
+		t.Errorf("equal registers should encode the same %x %x", data1, data2)
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"time"
+)
+
+// Source of the current time of every time dependent operation.
+// Types made without a clock use the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// The clock of the operating system
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// A clock that only moves when it is told to, for tests
+type ManualClock struct {
+	now time.Time
+}
+
+// Instantiate a new manual clock stopped at now
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+// Move the clock to now
+func (c *ManualClock) Set(now time.Time) {
+	c.now = now
+}
+
+// Move the clock forward by d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// ------------------- private functions -------------------
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock{}
+	}
+	return clock
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	clock := NewManualClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("clock should be stopped at start %v", clock.Now())
+	}
+
+	clock.Advance(time.Second)
+	if !clock.Now().Equal(start.Add(time.Second)) {
+		t.Errorf("clock should be advanced a second %v", clock.Now())
+	}
+
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("clock should be set back to start %v", clock.Now())
+	}
+}
+
+func TestLWWRegWithClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 1000))
+	lww1 := NewLWWRegWithClock(clock)
+	lww1.Assign("value1")
+	if lww1.Timestamp != 1000 {
+		t.Errorf("LWW1.timestamp should be 1000 %#v", lww1)
+	}
+
+	// a write at the same time loses to the greater value
+	lww1.Assign("value0")
+	if lww1.Value != "value1" {
+		t.Errorf("LWW1.value should be value1 %#v", lww1)
+	}
+
+	clock.Advance(time.Nanosecond)
+	lww1.AssignBy("value0", "a")
+	if lww1.Value != "value0" || lww1.Timestamp != 1001 {
+		t.Errorf("LWW1.value should be value0 %#v", lww1)
+	}
+}
+
+func TestDVVWithClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(100, 0))
+	vclock := NewDVVWithClock(clock)
+	vclock.Increment("a")
+	clock.Advance(time.Minute)
+	vclock.Increment("b")
+
+	if ts, _ := vclock.GetTimestamp("a"); ts != 100 {
+		t.Errorf("timestamp of a should be 100 but %d", ts)
+	}
+	if ts, _ := vclock.GetTimestamp("b"); ts != 160 {
+		t.Errorf("timestamp of b should be 160 but %d", ts)
+	}
+
+	props := map[string]int{
+		"small_vclock": 1,
+		"young_vclock": 1,
+		"big_vclock":   2,
+		"old_vclock":   1000,
+	}
+	clock.Advance(time.Hour)
+	vclock.Prune(props)
+	if vclock.Len() != 1 || vclock.GetCounter("b") != 1 {
+		t.Errorf("a should be pruned as old %#v", vclock)
+	}
+}
@@ -0,0 +1,478 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// A common interface of the crdts, so that replicas of any type can be
+// merged and compared without switching on the concrete type. The
+// methods wrap the typed methods of each type, which are kept as they are.
+
+package dt
+
+import (
+	"errors"
+)
+
+// Returned when merging or comparing crdts of different types
+var ErrTypeMismatch = errors.New("precondition: the crdts have different types")
+
+// Returned when merging a nil crdt
+var ErrNilCRDT = errors.New("precondition: the crdt is nil")
+
+// Every crdt implements CRDT with a pointer receiver
+type CRDT interface {
+	// The name of the type, such as "orswot". Instantiations of the
+	// generic types share the name.
+	TypeName() string
+	// A copy which can be updated without changing the crdt
+	CloneCRDT() CRDT
+	// true if the other crdt has the same type and is equal
+	EqualCRDT(other CRDT) bool
+	// Merge the other crdt into the crdt, or fail with ErrTypeMismatch
+	MergeCRDT(other CRDT) error
+	// The value of the crdt, as returned by its typed Value or Values
+	ValueCRDT() interface{}
+}
+
+var (
+	_ CRDT = (*GCounter)(nil)
+	_ CRDT = (*PNCounter)(nil)
+	_ CRDT = (*EMCounter)(nil)
+	_ CRDT = (*GSet)(nil)
+	_ CRDT = (*TwoPSet)(nil)
+	_ CRDT = (*LWWReg)(nil)
+	_ CRDT = (*MVRegister)(nil)
+	_ CRDT = (*ORSet)(nil)
+	_ CRDT = (*RWSet)(nil)
+	_ CRDT = (*ORSWOT)(nil)
+	_ CRDT = (*LWWElementSet)(nil)
+	_ CRDT = (*EWFlag)(nil)
+	_ CRDT = (*DWFlag)(nil)
+	_ CRDT = (*Map)(nil)
+	_ CRDT = (*DVV)(nil)
+	_ CRDT = (*DVVSet)(nil)
+)
+
+func (counter *GCounterOf[A]) TypeName() string { return "gcounter" }
+
+func (counter *GCounterOf[A]) CloneCRDT() CRDT {
+	acc := counter.Clone()
+	return &acc
+}
+
+func (counter_a *GCounterOf[A]) EqualCRDT(other CRDT) bool {
+	counter_b, ok := other.(*GCounterOf[A])
+	return ok && counter_b != nil && counter_a.Equal(*counter_b)
+}
+
+func (counter_a *GCounterOf[A]) MergeCRDT(other CRDT) error {
+	counter_b, ok := other.(*GCounterOf[A])
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if counter_b == nil {
+		return ErrNilCRDT
+	}
+	counter_a.MergeWith(*counter_b)
+	return nil
+}
+
+func (counter *GCounterOf[A]) ValueCRDT() interface{} { return counter.Value() }
+
+func (counter *PNCounterOf[A]) TypeName() string { return "pncounter" }
+
+func (counter *PNCounterOf[A]) CloneCRDT() CRDT {
+	acc := counter.Clone()
+	return &acc
+}
+
+func (counter_a *PNCounterOf[A]) EqualCRDT(other CRDT) bool {
+	counter_b, ok := other.(*PNCounterOf[A])
+	return ok && counter_b != nil && counter_a.Equal(*counter_b)
+}
+
+func (counter_a *PNCounterOf[A]) MergeCRDT(other CRDT) error {
+	counter_b, ok := other.(*PNCounterOf[A])
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if counter_b == nil {
+		return ErrNilCRDT
+	}
+	counter_a.MergeWith(*counter_b)
+	return nil
+}
+
+func (counter *PNCounterOf[A]) ValueCRDT() interface{} { return counter.Value() }
+
+func (counter *EMCounter) TypeName() string { return "emcounter" }
+
+func (counter *EMCounter) CloneCRDT() CRDT {
+	acc := counter.Clone()
+	return &acc
+}
+
+func (counter_a *EMCounter) EqualCRDT(other CRDT) bool {
+	counter_b, ok := other.(*EMCounter)
+	return ok && counter_b != nil && counter_a.Equal(*counter_b)
+}
+
+func (counter_a *EMCounter) MergeCRDT(other CRDT) error {
+	counter_b, ok := other.(*EMCounter)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if counter_b == nil {
+		return ErrNilCRDT
+	}
+	counter_a.Merge(*counter_b)
+	return nil
+}
+
+func (counter *EMCounter) ValueCRDT() interface{} { return counter.Value() }
+
+func (gset *GSetOf[T]) TypeName() string { return "gset" }
+
+func (gset *GSetOf[T]) CloneCRDT() CRDT {
+	acc := gset.Clone()
+	return &acc
+}
+
+func (gset_a *GSetOf[T]) EqualCRDT(other CRDT) bool {
+	gset_b, ok := other.(*GSetOf[T])
+	return ok && gset_b != nil && gset_a.Equal(*gset_b)
+}
+
+func (gset_a *GSetOf[T]) MergeCRDT(other CRDT) error {
+	gset_b, ok := other.(*GSetOf[T])
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if gset_b == nil {
+		return ErrNilCRDT
+	}
+	gset_a.MergeWith(*gset_b)
+	return nil
+}
+
+func (gset *GSetOf[T]) ValueCRDT() interface{} { return gset.Values() }
+
+func (twopset *TwoPSetOf[T]) TypeName() string { return "twopset" }
+
+func (twopset *TwoPSetOf[T]) CloneCRDT() CRDT {
+	acc := twopset.Clone()
+	return &acc
+}
+
+func (twopset_a *TwoPSetOf[T]) EqualCRDT(other CRDT) bool {
+	twopset_b, ok := other.(*TwoPSetOf[T])
+	return ok && twopset_b != nil && twopset_a.Equal(*twopset_b)
+}
+
+func (twopset_a *TwoPSetOf[T]) MergeCRDT(other CRDT) error {
+	twopset_b, ok := other.(*TwoPSetOf[T])
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if twopset_b == nil {
+		return ErrNilCRDT
+	}
+	twopset_a.MergeWith(*twopset_b)
+	return nil
+}
+
+func (twopset *TwoPSetOf[T]) ValueCRDT() interface{} { return twopset.Values() }
+
+func (reg *LWWRegOf[V]) TypeName() string { return "lwwreg" }
+
+func (reg *LWWRegOf[V]) CloneCRDT() CRDT {
+	acc := reg.Clone()
+	return &acc
+}
+
+func (reg_a *LWWRegOf[V]) EqualCRDT(other CRDT) bool {
+	reg_b, ok := other.(*LWWRegOf[V])
+	return ok && reg_b != nil && reg_a.Equal(*reg_b)
+}
+
+func (reg_a *LWWRegOf[V]) MergeCRDT(other CRDT) error {
+	reg_b, ok := other.(*LWWRegOf[V])
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if reg_b == nil {
+		return ErrNilCRDT
+	}
+	reg_a.Merge(*reg_b)
+	return nil
+}
+
+func (reg *LWWRegOf[V]) ValueCRDT() interface{} { return reg.Value }
+
+func (reg *MVRegister) TypeName() string { return "mvregister" }
+
+func (reg *MVRegister) CloneCRDT() CRDT {
+	acc := reg.Clone()
+	return &acc
+}
+
+func (reg_a *MVRegister) EqualCRDT(other CRDT) bool {
+	reg_b, ok := other.(*MVRegister)
+	return ok && reg_b != nil && reg_a.Equal(*reg_b)
+}
+
+func (reg_a *MVRegister) MergeCRDT(other CRDT) error {
+	reg_b, ok := other.(*MVRegister)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if reg_b == nil {
+		return ErrNilCRDT
+	}
+	reg_a.Merge(*reg_b)
+	return nil
+}
+
+// The siblings, without the causal context
+func (reg *MVRegister) ValueCRDT() interface{} {
+	values, _ := reg.Values()
+	return values
+}
+
+func (orset *ORSetOf[T]) TypeName() string { return "orset" }
+
+func (orset *ORSetOf[T]) CloneCRDT() CRDT {
+	acc := orset.Clone()
+	return &acc
+}
+
+func (orset_a *ORSetOf[T]) EqualCRDT(other CRDT) bool {
+	orset_b, ok := other.(*ORSetOf[T])
+	return ok && orset_b != nil && orset_a.Equal(*orset_b)
+}
+
+func (orset_a *ORSetOf[T]) MergeCRDT(other CRDT) error {
+	orset_b, ok := other.(*ORSetOf[T])
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if orset_b == nil {
+		return ErrNilCRDT
+	}
+	orset_a.Merge(*orset_b)
+	return nil
+}
+
+func (orset *ORSetOf[T]) ValueCRDT() interface{} { return orset.Value() }
+
+func (rwset *RWSet) TypeName() string { return "rwset" }
+
+func (rwset *RWSet) CloneCRDT() CRDT {
+	acc := rwset.Clone()
+	return &acc
+}
+
+func (rwset_a *RWSet) EqualCRDT(other CRDT) bool {
+	rwset_b, ok := other.(*RWSet)
+	return ok && rwset_b != nil && rwset_a.Equal(*rwset_b)
+}
+
+func (rwset_a *RWSet) MergeCRDT(other CRDT) error {
+	rwset_b, ok := other.(*RWSet)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if rwset_b == nil {
+		return ErrNilCRDT
+	}
+	rwset_a.Merge(*rwset_b)
+	return nil
+}
+
+func (rwset *RWSet) ValueCRDT() interface{} { return rwset.Value() }
+
+func (orswot *ORSWOT) TypeName() string { return "orswot" }
+
+func (orswot *ORSWOT) CloneCRDT() CRDT {
+	acc := orswot.Clone()
+	return &acc
+}
+
+func (orswot_a *ORSWOT) EqualCRDT(other CRDT) bool {
+	orswot_b, ok := other.(*ORSWOT)
+	return ok && orswot_b != nil && orswot_a.Equal(*orswot_b)
+}
+
+func (orswot_a *ORSWOT) MergeCRDT(other CRDT) error {
+	orswot_b, ok := other.(*ORSWOT)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if orswot_b == nil {
+		return ErrNilCRDT
+	}
+	orswot_a.Merge(*orswot_b)
+	return nil
+}
+
+func (orswot *ORSWOT) ValueCRDT() interface{} { return orswot.Value() }
+
+func (lwwset *LWWElementSet) TypeName() string { return "lwwelementset" }
+
+func (lwwset *LWWElementSet) CloneCRDT() CRDT {
+	acc := lwwset.Clone()
+	return &acc
+}
+
+func (lwwset_a *LWWElementSet) EqualCRDT(other CRDT) bool {
+	lwwset_b, ok := other.(*LWWElementSet)
+	return ok && lwwset_b != nil && lwwset_a.Equal(*lwwset_b)
+}
+
+func (lwwset_a *LWWElementSet) MergeCRDT(other CRDT) error {
+	lwwset_b, ok := other.(*LWWElementSet)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if lwwset_b == nil {
+		return ErrNilCRDT
+	}
+	return lwwset_a.Merge(*lwwset_b)
+}
+
+func (lwwset *LWWElementSet) ValueCRDT() interface{} { return lwwset.Value() }
+
+func (flag *EWFlag) TypeName() string { return "ewflag" }
+
+func (flag *EWFlag) CloneCRDT() CRDT {
+	acc := flag.Clone()
+	return &acc
+}
+
+func (flag_a *EWFlag) EqualCRDT(other CRDT) bool {
+	flag_b, ok := other.(*EWFlag)
+	return ok && flag_b != nil && flag_a.Equal(*flag_b)
+}
+
+func (flag_a *EWFlag) MergeCRDT(other CRDT) error {
+	flag_b, ok := other.(*EWFlag)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if flag_b == nil {
+		return ErrNilCRDT
+	}
+	flag_a.Merge(*flag_b)
+	return nil
+}
+
+func (flag *EWFlag) ValueCRDT() interface{} { return flag.Value() }
+
+func (flag *DWFlag) TypeName() string { return "dwflag" }
+
+func (flag *DWFlag) CloneCRDT() CRDT {
+	acc := flag.Clone()
+	return &acc
+}
+
+func (flag_a *DWFlag) EqualCRDT(other CRDT) bool {
+	flag_b, ok := other.(*DWFlag)
+	return ok && flag_b != nil && flag_a.Equal(*flag_b)
+}
+
+func (flag_a *DWFlag) MergeCRDT(other CRDT) error {
+	flag_b, ok := other.(*DWFlag)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if flag_b == nil {
+		return ErrNilCRDT
+	}
+	flag_a.Merge(*flag_b)
+	return nil
+}
+
+func (flag *DWFlag) ValueCRDT() interface{} { return flag.Value() }
+
+func (m *Map) TypeName() string { return "map" }
+
+func (m *Map) CloneCRDT() CRDT {
+	acc := m.Clone()
+	return &acc
+}
+
+func (m_a *Map) EqualCRDT(other CRDT) bool {
+	m_b, ok := other.(*Map)
+	return ok && m_b != nil && m_a.Equal(*m_b)
+}
+
+func (m_a *Map) MergeCRDT(other CRDT) error {
+	m_b, ok := other.(*Map)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if m_b == nil {
+		return ErrNilCRDT
+	}
+	m_a.Merge(*m_b)
+	return nil
+}
+
+func (m *Map) ValueCRDT() interface{} { return m.Value() }
+
+func (v *DVV) TypeName() string { return "dvv" }
+
+func (v *DVV) CloneCRDT() CRDT {
+	acc := v.Clone()
+	return &acc
+}
+
+func (va *DVV) EqualCRDT(other CRDT) bool {
+	vb, ok := other.(*DVV)
+	return ok && vb != nil && va.equal(*vb)
+}
+
+func (va *DVV) MergeCRDT(other CRDT) error {
+	vb, ok := other.(*DVV)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if vb == nil {
+		return ErrNilCRDT
+	}
+	va.Merge([]DVV{*vb})
+	return nil
+}
+
+// The counter of every node
+func (v *DVV) ValueCRDT() interface{} {
+	acc := map[string]uint32{}
+	for _, dot := range v.vector {
+		acc[dot.node] = dot.counter
+	}
+	return acc
+}
+
+func (c *DVVSet) TypeName() string { return "dvvset" }
+
+func (c *DVVSet) CloneCRDT() CRDT {
+	acc := c.Clone()
+	return &acc
+}
+
+func (c *DVVSet) EqualCRDT(other CRDT) bool {
+	c_b, ok := other.(*DVVSet)
+	return ok && c_b != nil && c.Equal(*c_b)
+}
+
+func (c *DVVSet) MergeCRDT(other CRDT) error {
+	c_b, ok := other.(*DVVSet)
+	if !ok {
+		return ErrTypeMismatch
+	}
+	if c_b == nil {
+		return ErrNilCRDT
+	}
+	c.Sync([]DVVSet{*c_b})
+	return nil
+}
+
+func (c *DVVSet) ValueCRDT() interface{} { return c.Values() }
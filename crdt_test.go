@@ -0,0 +1,152 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+// A replica of every type, updated by the actor
+var replicas = []func(actor string) CRDT{
+	func(actor string) CRDT {
+		counter := NewGCounter()
+		counter.Increment(actor)
+		return &counter
+	},
+	func(actor string) CRDT {
+		counter := NewPNCounter()
+		counter.IncrementBy(actor, 2)
+		return &counter
+	},
+	func(actor string) CRDT {
+		counter := NewEMCounter()
+		counter.Decrement(actor)
+		return &counter
+	},
+	func(actor string) CRDT {
+		gset := NewGSet()
+		gset.Add(actor)
+		return &gset
+	},
+	func(actor string) CRDT {
+		twopset := NewTwoPSet()
+		twopset.Add(actor)
+		return &twopset
+	},
+	func(actor string) CRDT {
+		reg := NewLWWReg()
+		reg.AssignByTS(actor, actor, int64(len(actor)))
+		return &reg
+	},
+	func(actor string) CRDT {
+		reg := NewMVRegister()
+		reg.Assign(actor, NewDVV(), actor)
+		return &reg
+	},
+	func(actor string) CRDT {
+		orset := NewOrset()
+		orset.Add(actor, actor)
+		return &orset
+	},
+	func(actor string) CRDT {
+		rwset := NewRWSet()
+		rwset.Add(actor, actor)
+		return &rwset
+	},
+	func(actor string) CRDT {
+		orswot := NewORSWOT()
+		orswot.Add(actor, actor)
+		return &orswot
+	},
+	func(actor string) CRDT {
+		lwwset := NewLWWElementSet(AddWins)
+		lwwset.AddTS(actor, actor, 1)
+		return &lwwset
+	},
+	func(actor string) CRDT {
+		flag := NewEWFlag()
+		flag.Enable(actor)
+		return &flag
+	},
+	func(actor string) CRDT {
+		flag := NewDWFlag()
+		flag.Disable(actor)
+		return &flag
+	},
+	func(actor string) CRDT {
+		m := NewMap()
+		m.Update(actor, UpdateCounter(actor, func(c *EMCounter) {
+			c.Increment(actor)
+		}))
+		return &m
+	},
+	func(actor string) CRDT {
+		v := NewDVV()
+		v.Increment(actor)
+		return &v
+	},
+	func(actor string) CRDT {
+		c := DVVSet{}
+		c.Update(NewDVVSet(actor), actor)
+		return &c
+	},
+}
+
+func TestCRDTMerge(t *testing.T) {
+	names := map[string]bool{}
+	for _, replica := range replicas {
+		a := replica("a")
+		b := replica("bb")
+		name := a.TypeName()
+		if names[name] {
+			t.Errorf("%s: type names should be unique", name)
+		}
+		names[name] = true
+
+		value := a.ValueCRDT()
+		merged_a := a.CloneCRDT()
+		if err := merged_a.MergeCRDT(b); err != nil {
+			t.Errorf("%s: merge should succeed %v", name, err)
+		}
+		merged_b := b.CloneCRDT()
+		merged_b.MergeCRDT(a)
+
+		if !merged_a.EqualCRDT(merged_b) {
+			t.Errorf("%s: merge should be commutative %#v %#v", name, merged_a, merged_b)
+		}
+		again := merged_a.CloneCRDT()
+		again.MergeCRDT(merged_a)
+		if !again.EqualCRDT(merged_a) {
+			t.Errorf("%s: merge should be idempotent %#v", name, again)
+		}
+		if !reflect.DeepEqual(a.ValueCRDT(), value) {
+			t.Errorf("%s: merging into a clone shouldn't change the crdt %#v", name, a)
+		}
+	}
+}
+
+func TestCRDTTypeMismatch(t *testing.T) {
+	gset := NewGSet()
+	counter := NewGCounter()
+	if err := gset.MergeCRDT(&counter); err != ErrTypeMismatch {
+		t.Errorf("merging another type should fail %v", err)
+	}
+	if gset.EqualCRDT(&counter) {
+		t.Error("crdts of different types shouldn't be equal")
+	}
+}
+
+func TestCRDTNil(t *testing.T) {
+	for _, replica := range replicas {
+		a := replica("a")
+		typed_nil := reflect.Zero(reflect.TypeOf(a)).Interface().(CRDT)
+		if a.EqualCRDT(typed_nil) {
+			t.Errorf("%s: a crdt shouldn't be equal to nil", a.TypeName())
+		}
+		if err := a.MergeCRDT(typed_nil); err != ErrNilCRDT {
+			t.Errorf("%s: merging nil should fail %v", a.TypeName(), err)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// Dotted Version Vector Sets implementation
+// based on http://haslab.uminho.pt/tome/files/dvvset-dais.pdf
+
+package dt
+
+import (
+	"sort"
+
+	"golang.org/x/xerrors"
+)
+
+// Counter of a node with the values of its latest events, newest first.
+// The values are the events counter-len(values)+1 to counter.
+type dvvEntry struct {
+	node    string
+	counter uint32
+	values  []string
+}
+
+// A clock of sibling values. Entries are sorted by node, and
+// anonymous values are the ones without an event of their own yet.
+type DVVSet struct {
+	entries   []dvvEntry
+	anonymous []string
+}
+
+// Instantiate a new dvvset with values without causal history
+func NewDVVSet(values ...string) DVVSet {
+	return DVVSet{anonymous: copyValues(values)}
+}
+
+// Instantiate a new dvvset with values written by a client that
+// has read the causal context
+func NewDVVSetWithContext(context DVV, values ...string) DVVSet {
+	c := DVVSet{anonymous: copyValues(values)}
+	for _, dot := range context.vector {
+		c.entries = append(c.entries, dvvEntry{node: dot.node, counter: dot.counter})
+	}
+	sort.Slice(c.entries, func(i, j int) bool {
+		return c.entries[i].node < c.entries[j].node
+	})
+	return c
+}
+
+// Synchronize all clocks in the input list into a clock with
+// the values that are not obsoleted by any of them.
+func (c *DVVSet) Sync(clocks []DVVSet) {
+	acc := *c
+	for _, clock := range clocks {
+		acc = syncDVVSet(acc, clock)
+	}
+	*c = acc
+}
+
+// Return the version vector that summarizes the causal history of the clock
+func (c DVVSet) Join() DVV {
+	acc := NewDVV()
+	for _, e := range c.entries {
+		acc.vector = append(acc.vector, Dot{node: e.node, counter: e.counter})
+	}
+	return acc
+}
+
+// Advance the clock with a new event of the node. The client clock
+// must have a single value, made by NewDVVSet or NewDVVSetWithContext,
+// and the values its context has seen are discarded from the clock.
+func (c *DVVSet) Update(client DVVSet, node string) error {
+	if len(client.anonymous) != 1 {
+		return xerrors.New("client clock should have exactly one value")
+	}
+	synced := syncDVVSet(DVVSet{entries: client.entries}, *c)
+	c.entries = event(synced.entries, node, client.anonymous[0])
+	c.anonymous = synced.anonymous
+	return nil
+}
+
+// Return all values of the clock
+func (c DVVSet) Values() []string {
+	acc := []string{}
+	acc = append(acc, c.anonymous...)
+	for _, e := range c.entries {
+		acc = append(acc, e.values...)
+	}
+	return acc
+}
+
+// Return the number of values of the clock
+func (c DVVSet) Size() int {
+	acc := len(c.anonymous)
+	for _, e := range c.entries {
+		acc = acc + len(e.values)
+	}
+	return acc
+}
+
+// Return the nodes of the clock
+func (c DVVSet) Ids() []string {
+	acc := []string{}
+	for _, e := range c.entries {
+		acc = append(acc, e.node)
+	}
+	return acc
+}
+
+// true if the causal history of the clock is strictly contained
+// in the causal history of the other clock
+func (c DVVSet) Less(other DVVSet) bool {
+	return greater(other.entries, c.entries, false)
+}
+
+// true if both clocks have the same causal history and number of
+// values per node. Anonymous values are ignored.
+func (c DVVSet) Equal(other DVVSet) bool {
+	if len(c.entries) != len(other.entries) {
+		return false
+	}
+	for i, e := range c.entries {
+		o := other.entries[i]
+		if e.node != o.node || e.counter != o.counter || len(e.values) != len(o.values) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return a copy of the clock
+func (c DVVSet) Clone() DVVSet {
+	acc := DVVSet{anonymous: copyValues(c.anonymous)}
+	for _, e := range c.entries {
+		acc.entries = append(acc.entries, dvvEntry{node: e.node, counter: e.counter, values: copyValues(e.values)})
+	}
+	return acc
+}
+
+// Replace all values of the clock with the one f makes from them
+func (c *DVVSet) Reconcile(f func([]string) string) {
+	value := f(c.Values())
+	c.entries = clearValues(c.entries, "", nil)
+	c.anonymous = []string{value}
+}
+
+// Return the latest value of the clock, ordered by less
+func (c DVVSet) Last(less func(string, string) bool) string {
+	_, value, _, _ := c.findEntry(less)
+	return value
+}
+
+// Keep only the latest value of the clock, ordered by less
+func (c *DVVSet) LWW(less func(string, string) bool) {
+	node, value, anonymous, ok := c.findEntry(less)
+	if !ok {
+		return
+	}
+	if anonymous {
+		c.entries = clearValues(c.entries, "", nil)
+		c.anonymous = []string{value}
+		return
+	}
+	c.entries = clearValues(c.entries, node, []string{value})
+	c.anonymous = nil
+}
+
+// ------------------- private functions -------------------
+
+func syncDVVSet(c1 DVVSet, c2 DVVSet) DVVSet {
+	var anonymous []string
+	if c1.Less(c2) {
+		anonymous = c2.anonymous
+	} else if c2.Less(c1) {
+		anonymous = c1.anonymous
+	} else {
+		seen := map[string]bool{}
+		for _, v := range append(copyValues(c1.anonymous), c2.anonymous...) {
+			if !seen[v] {
+				seen[v] = true
+				anonymous = append(anonymous, v)
+			}
+		}
+	}
+	return DVVSet{entries: syncEntries(c1.entries, c2.entries), anonymous: anonymous}
+}
+
+func syncEntries(e1 []dvvEntry, e2 []dvvEntry) []dvvEntry {
+	var acc []dvvEntry
+	for len(e1) > 0 && len(e2) > 0 {
+		if e1[0].node < e2[0].node {
+			acc = append(acc, e1[0])
+			e1 = e1[1:]
+		} else if e1[0].node > e2[0].node {
+			acc = append(acc, e2[0])
+			e2 = e2[1:]
+		} else {
+			acc = append(acc, mergeEntry(e1[0], e2[0]))
+			e1 = e1[1:]
+			e2 = e2[1:]
+		}
+	}
+	acc = append(acc, e1...)
+	acc = append(acc, e2...)
+	return acc
+}
+
+// Keep the values of the events which are unknown to either entry
+func mergeEntry(e1 dvvEntry, e2 dvvEntry) dvvEntry {
+	n1, l1 := int(e1.counter), len(e1.values)
+	n2, l2 := int(e2.counter), len(e2.values)
+	if n1 >= n2 {
+		if n1-l1 >= n2-l2 {
+			return e1
+		}
+		return dvvEntry{node: e1.node, counter: e1.counter, values: e1.values[:n1-n2+l2]}
+	}
+	if n2-l2 >= n1-l1 {
+		return e2
+	}
+	return dvvEntry{node: e2.node, counter: e2.counter, values: e2.values[:n2-n1+l1]}
+}
+
+// true if the entries e1 strictly (or not, if strict is false
+// and they are equal) contain the causal history of e2
+func greater(e1 []dvvEntry, e2 []dvvEntry, strict bool) bool {
+	for {
+		if len(e1) == 0 && len(e2) == 0 {
+			return strict
+		}
+		if len(e2) == 0 {
+			return true
+		}
+		if len(e1) == 0 {
+			return false
+		}
+		if e1[0].node == e2[0].node {
+			if e1[0].counter > e2[0].counter {
+				strict = true
+			} else if e1[0].counter < e2[0].counter {
+				return false
+			}
+			e1 = e1[1:]
+			e2 = e2[1:]
+		} else if e1[0].node < e2[0].node {
+			strict = true
+			e1 = e1[1:]
+		} else {
+			return false
+		}
+	}
+}
+
+// Register a new event of the node with the value
+func event(entries []dvvEntry, node string, value string) []dvvEntry {
+	idx := sort.Search(len(entries), func(i int) bool {
+		return entries[i].node >= node
+	})
+	acc := make([]dvvEntry, 0, len(entries)+1)
+	acc = append(acc, entries[:idx]...)
+	if idx < len(entries) && entries[idx].node == node {
+		e := entries[idx]
+		values := append([]string{value}, e.values...)
+		acc = append(acc, dvvEntry{node: node, counter: e.counter + 1, values: values})
+		idx = idx + 1
+	} else {
+		acc = append(acc, dvvEntry{node: node, counter: 1, values: []string{value}})
+	}
+	return append(acc, entries[idx:]...)
+}
+
+// Return the node and the latest value ordered by less, and whether
+// the value is anonymous. Entries are searched before anonymous values.
+func (c DVVSet) findEntry(less func(string, string) bool) (string, string, bool, bool) {
+	var node, value string
+	var anonymous, found bool
+	for _, e := range c.entries {
+		if len(e.values) == 0 {
+			continue
+		}
+		if !found || less(value, e.values[0]) {
+			node, value, anonymous, found = e.node, e.values[0], false, true
+		}
+	}
+	for _, v := range c.anonymous {
+		if !found || less(value, v) {
+			value, anonymous, found = v, true, true
+		}
+	}
+	return node, value, anonymous, found
+}
+
+// Drop the values of all entries, except the node which gets values
+func clearValues(entries []dvvEntry, node string, values []string) []dvvEntry {
+	var acc []dvvEntry
+	for _, e := range entries {
+		e.values = nil
+		if e.node == node {
+			e.values = values
+		}
+		acc = append(acc, e)
+	}
+	return acc
+}
+
+func copyValues(values []string) []string {
+	return append([]string(nil), values...)
+}
@@ -0,0 +1,254 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDVVSetJoin(t *testing.T) {
+	a := NewDVVSet("v1")
+	a1 := DVVSet{}
+	a1.Update(a, "a")
+	b := NewDVVSetWithContext(a1.Join(), "v2")
+	b1 := a1
+	b1.Update(b, "b")
+
+	if a.Join().Len() != 0 {
+		t.Errorf("join of a should be empty %#v", a.Join())
+	}
+	if !reflect.DeepEqual(a1.Join().vector, []Dot{{node: "a", counter: 1}}) {
+		t.Errorf("join of a1 should be a:1 %#v", a1.Join())
+	}
+	expected := []Dot{{node: "a", counter: 1}, {node: "b", counter: 1}}
+	if !reflect.DeepEqual(b1.Join().vector, expected) {
+		t.Errorf("join of b1 should be a:1, b:1 %#v", b1.Join())
+	}
+}
+
+func TestDVVSetUpdate(t *testing.T) {
+	a0 := DVVSet{}
+	a0.Update(NewDVVSet("v1"), "a")
+	a1 := a0
+	a1.Update(NewDVVSetWithContext(a0.Join(), "v2"), "a")
+	a2 := a1
+	a2.Update(NewDVVSetWithContext(a1.Join(), "v3"), "b")
+	a3 := a1
+	a3.Update(NewDVVSetWithContext(a0.Join(), "v4"), "b")
+	a4 := a1
+	a4.Update(NewDVVSetWithContext(a0.Join(), "v5"), "a")
+
+	cases := []struct {
+		clock    DVVSet
+		expected DVVSet
+	}{
+		{a0, DVVSet{entries: []dvvEntry{{"a", 1, []string{"v1"}}}}},
+		{a1, DVVSet{entries: []dvvEntry{{"a", 2, []string{"v2"}}}}},
+		{a2, DVVSet{entries: []dvvEntry{{"a", 2, nil}, {"b", 1, []string{"v3"}}}}},
+		{a3, DVVSet{entries: []dvvEntry{{"a", 2, []string{"v2"}}, {"b", 1, []string{"v4"}}}}},
+		{a4, DVVSet{entries: []dvvEntry{{"a", 3, []string{"v5", "v2"}}}}},
+	}
+	for i, c := range cases {
+		if !sameDVVSet(c.clock, c.expected) {
+			t.Errorf("case %d: clock should be %#v but %#v", i, c.expected, c.clock)
+		}
+	}
+
+	if err := a0.Update(NewDVVSet("v1", "v2"), "a"); err == nil {
+		t.Error("update with two values should fail")
+	}
+}
+
+func TestDVVSetSync(t *testing.T) {
+	x := DVVSet{entries: []dvvEntry{{"x", 1, nil}}}
+	a := DVVSet{}
+	a.Update(NewDVVSet("v1"), "a")
+	y := DVVSet{}
+	y.Update(NewDVVSet("v2"), "b")
+	a1 := DVVSet{}
+	a1.Update(NewDVVSetWithContext(a.Join(), "v2"), "a")
+	a3 := DVVSet{}
+	a3.Update(NewDVVSetWithContext(a1.Join(), "v3"), "b")
+	a4 := DVVSet{}
+	a4.Update(NewDVVSetWithContext(a1.Join(), "v3"), "c")
+	w := DVVSet{entries: []dvvEntry{{"a", 1, nil}}}
+	z := DVVSet{entries: []dvvEntry{{"a", 2, []string{"v2", "v1"}}}}
+
+	sync := func(clocks ...DVVSet) DVVSet {
+		acc := DVVSet{}
+		acc.Sync(clocks)
+		return acc
+	}
+
+	cases := []struct {
+		clock    DVVSet
+		expected DVVSet
+	}{
+		{sync(w, z), DVVSet{entries: []dvvEntry{{"a", 2, []string{"v2"}}}}},
+		{sync(z, w), sync(w, z)},
+		{sync(a, a1), sync(a1, a)},
+		{sync(a4, a3), sync(a3, a4)},
+		{sync(a4, a3), DVVSet{entries: []dvvEntry{{"a", 2, nil}, {"b", 1, []string{"v3"}}, {"c", 1, []string{"v3"}}}}},
+		{sync(x, a), DVVSet{entries: []dvvEntry{{"a", 1, []string{"v1"}}, {"x", 1, nil}}}},
+		{sync(a, x), sync(x, a)},
+		{sync(a, y), DVVSet{entries: []dvvEntry{{"a", 1, []string{"v1"}}, {"b", 1, []string{"v2"}}}}},
+		{sync(y, a), sync(a, y)},
+	}
+	for i, c := range cases {
+		if !sameDVVSet(c.clock, c.expected) {
+			t.Errorf("case %d: clock should be %#v but %#v", i, c.expected, c.clock)
+		}
+	}
+}
+
+func TestDVVSetSyncUpdate(t *testing.T) {
+	// Mimics Riak: two clients write concurrently after reading the same key
+	a0 := DVVSet{}
+	a0.Update(NewDVVSet("v1"), "a")
+	k1 := a0.Join()
+	a1 := a0
+	a1.Update(NewDVVSetWithContext(k1, "v2"), "a")
+	a2 := a1
+	a2.Update(NewDVVSetWithContext(k1, "v3"), "a")
+
+	if !reflect.DeepEqual(a2.Values(), []string{"v3", "v2"}) {
+		t.Errorf("concurrent writes should be siblings %#v", a2.Values())
+	}
+
+	a3 := a2
+	a3.Update(NewDVVSetWithContext(a2.Join(), "v4"), "a")
+	if !reflect.DeepEqual(a3.Values(), []string{"v4"}) {
+		t.Errorf("write with the latest context should replace siblings %#v", a3.Values())
+	}
+}
+
+func TestDVVSetLess(t *testing.T) {
+	a := DVVSet{}
+	a.Update(NewDVVSet("v1"), "a")
+	b := DVVSet{}
+	b.Update(NewDVVSetWithContext(a.Join(), "v2"), "a")
+	b2 := DVVSet{}
+	b2.Update(NewDVVSetWithContext(a.Join(), "v2"), "b")
+	b3 := DVVSet{}
+	b3.Update(NewDVVSetWithContext(a.Join(), "v2"), "z")
+	c := b
+	c.Update(NewDVVSetWithContext(b.Join(), "v3"), "a")
+	d := c
+	d.Update(NewDVVSetWithContext(c.Join(), "v4"), "a")
+
+	if !a.Less(b) || !a.Less(c) || !b.Less(c) || !b.Less(d) {
+		t.Error("clocks should be less than their descendants")
+	}
+	if b2.Less(c) {
+		t.Error("b2 shouldn't be less than c")
+	}
+	if b.Less(b) || b.Less(a) || c.Less(b) {
+		t.Error("clocks shouldn't be less than themselves or their ancestors")
+	}
+	if b.Less(b2) || b2.Less(b) || b3.Less(b2) || b2.Less(b3) {
+		t.Error("concurrent clocks shouldn't be less than each other")
+	}
+}
+
+func TestDVVSetEqual(t *testing.T) {
+	a := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v5", "v0"}}, {"b", 0, nil}, {"c", 1, []string{"v3"}}}, anonymous: []string{"v0"}}
+	b := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v555", "v0"}}, {"b", 0, nil}, {"c", 1, []string{"v3"}}}}
+	c := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v5", "v0"}}, {"b", 0, nil}}, anonymous: []string{"v6", "v1"}}
+
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Error("a should be equal with b")
+	}
+	if a.Equal(c) || b.Equal(c) {
+		t.Error("c shouldn't be equal with a or b")
+	}
+}
+
+func TestDVVSetSize(t *testing.T) {
+	if NewDVVSet("v1").Size() != 1 {
+		t.Error("size should be 1")
+	}
+	c := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v5", "v0"}}, {"b", 0, nil}, {"c", 1, []string{"v3"}}}, anonymous: []string{"v4", "v1"}}
+	if c.Size() != 5 {
+		t.Errorf("size should be 5 but %d", c.Size())
+	}
+}
+
+func TestDVVSetValues(t *testing.T) {
+	a := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v0", "v5"}}, {"b", 0, nil}, {"c", 1, []string{"v3"}}}, anonymous: []string{"v1"}}
+	if !reflect.DeepEqual(a.Values(), []string{"v1", "v0", "v5", "v3"}) {
+		t.Errorf("values should be v1, v0, v5 and v3 %#v", a.Values())
+	}
+	if !reflect.DeepEqual(a.Ids(), []string{"a", "b", "c"}) {
+		t.Errorf("ids should be a, b and c %#v", a.Ids())
+	}
+}
+
+func TestDVVSetReconcile(t *testing.T) {
+	a := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v5", "v0"}}, {"b", 0, nil}, {"c", 1, []string{"v3"}}}, anonymous: []string{"v1"}}
+	a.Reconcile(func(values []string) string {
+		acc := ""
+		for _, v := range values {
+			acc = acc + v
+		}
+		return acc
+	})
+	expected := DVVSet{entries: []dvvEntry{{"a", 4, nil}, {"b", 0, nil}, {"c", 1, nil}}, anonymous: []string{"v1v5v0v3"}}
+	if !sameDVVSet(a, expected) {
+		t.Errorf("clock should be %#v but %#v", expected, a)
+	}
+}
+
+func TestDVVSetLWW(t *testing.T) {
+	less := func(l, r string) bool { return l < r }
+	a := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v5", "v0"}}, {"b", 0, nil}, {"c", 1, []string{"v3"}}}}
+	b := DVVSet{entries: []dvvEntry{{"a", 5, []string{"v6", "v0"}}, {"b", 1, []string{"v7"}}}}
+	c := DVVSet{entries: a.entries, anonymous: []string{"v10"}}
+
+	if a.Last(less) != "v5" || b.Last(less) != "v7" || c.Last(less) != "v5" {
+		t.Error("last should be the greatest value")
+	}
+
+	a.LWW(less)
+	expected := DVVSet{entries: []dvvEntry{{"a", 4, []string{"v5"}}, {"b", 0, nil}, {"c", 1, nil}}}
+	if !sameDVVSet(a, expected) {
+		t.Errorf("clock should be %#v but %#v", expected, a)
+	}
+
+	b.LWW(less)
+	expected = DVVSet{entries: []dvvEntry{{"a", 5, nil}, {"b", 1, []string{"v7"}}}}
+	if !sameDVVSet(b, expected) {
+		t.Errorf("clock should be %#v but %#v", expected, b)
+	}
+
+	c.LWW(func(l, r string) bool { return len(l) < len(r) })
+	expected = DVVSet{entries: []dvvEntry{{"a", 4, nil}, {"b", 0, nil}, {"c", 1, nil}}, anonymous: []string{"v10"}}
+	if !sameDVVSet(c, expected) {
+		t.Errorf("clock should be %#v but %#v", expected, c)
+	}
+}
+
+// structural equality which doesn't tell nil slices from empty ones
+func sameDVVSet(a DVVSet, b DVVSet) bool {
+	if len(a.entries) != len(b.entries) || len(a.anonymous) != len(b.anonymous) {
+		return false
+	}
+	for i, e := range a.entries {
+		o := b.entries[i]
+		if e.node != o.node || e.counter != o.counter || len(e.values) != len(o.values) {
+			return false
+		}
+		for j := range e.values {
+			if e.values[j] != o.values[j] {
+				return false
+			}
+		}
+	}
+	for i := range a.anonymous {
+		if a.anonymous[i] != b.anonymous[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// A counter which can be reset, or embedded in a map and removed
+// based on riak_dt_emcntr
+
+package dt
+
+// The increments and decrements of an actor, tagged with the counter
+// of the dot of its last update
+type EMEntry struct {
+	Counter uint32
+	P       uint
+	N       uint
+}
+
+// Each update replaces the entry of the actor with one carrying a new dot
+// and its running totals, so the state is one entry per actor like the
+// one of riak_dt_emcntr. An entry that is missing but whose dot is
+// covered by the clock has been reset. An actor which updates
+// concurrently with a reset carries its totals over it, so the reset
+// clears only the actors which have stopped updating.
+type EMCounter struct {
+	Clock   DVV
+	Entries map[string]EMEntry
+}
+
+// Create a new emcounter
+func NewEMCounter() EMCounter {
+	return EMCounter{Clock: NewDVV(), Entries: map[string]EMEntry{}}
+}
+
+// Create a new emcounter whose dots read timestamps from the clock
+func NewEMCounterWithClock(clock Clock) EMCounter {
+	return EMCounter{Clock: NewDVVWithClock(clock), Entries: map[string]EMEntry{}}
+}
+
+// The single total value of a emcounter
+func (counter EMCounter) Value() int {
+	acc := 0
+	for _, entry := range counter.Entries {
+		acc = acc + int(entry.P) - int(entry.N)
+	}
+	return acc
+}
+
+// increment counter for the actor by 1
+func (counter *EMCounter) Increment(actor string) {
+	counter.IncrementBy(actor, 1)
+}
+
+// perform the increment
+func (counter *EMCounter) IncrementBy(actor string, amount uint) {
+	entry := counter.Entries[actor]
+	entry.P = entry.P + amount
+	counter.update(actor, entry)
+}
+
+// decrement counter for the actor by 1
+func (counter *EMCounter) Decrement(actor string) {
+	counter.DecrementBy(actor, 1)
+}
+
+// perform the decrement
+func (counter *EMCounter) DecrementBy(actor string, amount uint) {
+	entry := counter.Entries[actor]
+	entry.N = entry.N + amount
+	counter.update(actor, entry)
+}
+
+// Reset the counter to 0. Only the updates which have been observed
+// are cleared, so concurrent updates survive the merge.
+func (counter *EMCounter) Reset() {
+	counter.Entries = map[string]EMEntry{}
+}
+
+// Merge two emcounter to a single emcounter. The entry with the newer dot
+// wins, and entries only one side has are kept when the other side has
+// not seen them.
+func (counter_a *EMCounter) Merge(counter_b EMCounter) {
+	entries := map[string]EMEntry{}
+	for actor, entry_a := range counter_a.Entries {
+		entry_b, ok := counter_b.Entries[actor]
+		if ok && entry_b.Counter > entry_a.Counter {
+			entries[actor] = entry_b
+		} else if ok || counter_b.Clock.GetCounter(actor) < entry_a.Counter {
+			entries[actor] = entry_a
+		}
+	}
+	for actor, entry_b := range counter_b.Entries {
+		if _, ok := counter_a.Entries[actor]; ok {
+			continue
+		}
+		if counter_a.Clock.GetCounter(actor) < entry_b.Counter {
+			entries[actor] = entry_b
+		}
+	}
+	counter_a.Clock.Merge([]DVV{counter_b.Clock})
+	counter_a.Entries = entries
+}
+
+// Compare two emcounter for equality
+func (counter_a EMCounter) Equal(counter_b EMCounter) bool {
+	if !counter_a.Clock.equal(counter_b.Clock) || len(counter_a.Entries) != len(counter_b.Entries) {
+		return false
+	}
+	for actor, entry_a := range counter_a.Entries {
+		if entry_b, ok := counter_b.Entries[actor]; !ok || entry_a != entry_b {
+			return false
+		}
+	}
+	return true
+}
+
+// Return a copy of the counter
+func (counter EMCounter) Clone() EMCounter {
+	acc := EMCounter{Clock: counter.Clock, Entries: map[string]EMEntry{}}
+	for actor, entry := range counter.Entries {
+		acc.Entries[actor] = entry
+	}
+	return acc
+}
+
+// ------------------- private functions -------------------
+
+// Store the entry of the actor with a new dot
+func (counter *EMCounter) update(actor string, entry EMEntry) {
+	counter.Clock.Increment(actor)
+	entry.Counter = counter.Clock.GetCounter(actor)
+	counter.Entries[actor] = entry
+}
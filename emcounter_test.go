@@ -0,0 +1,120 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewEMCounter(t *testing.T) {
+	counter := NewEMCounter()
+	if counter.Value() != 0 {
+		t.Errorf("emcounter.value should be 0 %#v", counter)
+	}
+}
+
+func TestEMCounterUpdate(t *testing.T) {
+	counter := NewEMCounter()
+	counter.IncrementBy("a", 5)
+	counter.Increment("b")
+	counter.Decrement("a")
+	counter.DecrementBy("b", 3)
+
+	if counter.Value() != 2 {
+		t.Errorf("emcounter.value should be 2 but %d", counter.Value())
+	}
+	if counter.Entries["a"] != (EMEntry{Counter: 2, P: 5, N: 1}) {
+		t.Errorf("entry of a should have the dot of its last update %#v", counter.Entries["a"])
+	}
+}
+
+func TestEMCounterMerge(t *testing.T) {
+	counter_a := NewEMCounter()
+	counter_a.IncrementBy("a", 3)
+	counter_b := NewEMCounter()
+	counter_b.Merge(counter_a)
+	counter_b.IncrementBy("a", 2)
+	counter_b.Decrement("b")
+
+	merged_a := counter_a
+	merged_a.Merge(counter_b)
+	merged_b := counter_b
+	merged_b.Merge(counter_a)
+
+	if merged_a.Value() != 4 {
+		t.Errorf("emcounter.value should be 4 but %d", merged_a.Value())
+	}
+	if !merged_a.Equal(merged_b) {
+		t.Errorf("merge should be commutative %#v %#v", merged_a, merged_b)
+	}
+}
+
+func TestEMCounterReset(t *testing.T) {
+	counter_a := NewEMCounter()
+	counter_a.IncrementBy("a", 3)
+	counter_b := NewEMCounter()
+	counter_b.Merge(counter_a)
+
+	// a resets while b concurrently increments
+	counter_a.Reset()
+	counter_b.IncrementBy("b", 2)
+
+	counter_a.Merge(counter_b)
+	if counter_a.Value() != 2 {
+		t.Errorf("only the concurrent increment should survive %d", counter_a.Value())
+	}
+
+	// the increments after the reset start from 0
+	counter_a.Increment("a")
+	counter_b.Merge(counter_a)
+	if counter_b.Value() != 3 {
+		t.Errorf("emcounter.value should be 3 but %d", counter_b.Value())
+	}
+}
+
+func TestEMCounterResetSameActor(t *testing.T) {
+	counter_b := NewEMCounter()
+	for i := 0; i < 5; i++ {
+		counter_b.Increment("b")
+	}
+	counter_a := NewEMCounter()
+	counter_a.Merge(counter_b)
+
+	// a resets while b concurrently increments again
+	counter_a.Reset()
+	counter_b.Increment("b")
+
+	merged_a := counter_a.Clone()
+	merged_a.Merge(counter_b)
+	merged_b := counter_b.Clone()
+	merged_b.Merge(counter_a)
+
+	// the entry of b carries the totals of b over the reset
+	if merged_a.Value() != 6 || merged_b.Value() != 6 {
+		t.Errorf("the entry of b should survive the reset %d %d", merged_a.Value(), merged_b.Value())
+	}
+	if !merged_a.Equal(merged_b) {
+		t.Errorf("merge should be commutative %#v %#v", merged_a, merged_b)
+	}
+}
+
+func TestEMCounterStateSize(t *testing.T) {
+	counter := NewEMCounterWithClock(NewManualClock(time.Unix(1600000000, 0)))
+	for i := 0; i < 100; i++ {
+		counter.IncrementBy("a", 2)
+		counter.DecrementBy("a", 2)
+	}
+	before, _ := counter.MarshalBinary()
+	// the counters stay within two bytes of uvarint
+	for i := 0; i < 4000; i++ {
+		counter.Increment("a")
+		counter.Decrement("a")
+	}
+	after, _ := counter.MarshalBinary()
+
+	if len(counter.Entries) != 1 || len(before) != len(after) {
+		t.Errorf("state should stay the same size %d %d %#v", len(before), len(after), counter.Entries)
+	}
+}
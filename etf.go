@@ -0,0 +1,851 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// Erlang external term format encoding, for exchanging state with
+// riak_core and riak_dt. Vclocks are encoded like riak_core_vclock
+// terms, with term_to_binary alone:
+//
+//	[{Node, {Counter, Timestamp}}]
+//
+// where timestamps are gregorian seconds, as riak_core_vclock uses.
+// The riak_dt types are framed like riak_dt to_binary, as a type tag
+// byte and a version byte followed by term_to_binary of the state:
+//
+//	gcounter   70, 1, [{Actor, Count}]
+//	pncounter  71, 2, [{Actor, Inc, Dec}]
+//	gset       72, 1, [Elem]
+//	lwwreg     74, 1, {Value, Timestamp}
+//	orswot     75, 1, {[{Actor, Count}], [{Elem, [{Actor, Count}]}], []}
+//
+// Lists are sorted like the orddicts and ordsets of riak_dt. The entries
+// and deferred removes of orswot are orddicts in version 1 and dicts in
+// version 2, which riak_dt writes. Both versions are decoded, and
+// version 1 is encoded as dicts can't be written without the hash of
+// Erlang. Strings and byte slices are encoded as binaries, and actors,
+// elements and values of other types than strings, byte slices and
+// integers can't be encoded. riak_dt has no actor in lwwreg and no
+// timestamps in the dots of orswot, so they are lost when encoded.
+// Timestamps of lwwreg are nanoseconds, as Assign writes them, and
+// riak_dt_lwwreg writes microseconds, so they are truncated to
+// microseconds when encoded. Registers written with hlc timestamps can't
+// be exchanged with riak_dt. The deferred removes of an orswot are
+// always empty, and decoding fails when they are not.
+
+package dt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+
+	"golang.org/x/xerrors"
+)
+
+// Seconds from the gregorian epoch of riak_core_vclock to the unix epoch
+const gregorianUnixOffset = 62167219200
+
+// Timestamps of lwwreg are nanoseconds here and microseconds in riak_dt
+const nanosPerMicro = 1000
+
+// Type tags and versions of riak_dt to_binary
+const (
+	etfGCounterTag  = 70
+	etfPNCounterTag = 71
+	etfGSetTag      = 72
+	etfLWWRegTag    = 74
+	etfORSWOTTag    = 75
+
+	etfGCounterVersion  = 1
+	etfPNCounterVersion = 2
+	etfGSetVersion      = 1
+	etfLWWRegVersion    = 1
+	etfORSWOTVersion    = 1
+
+	etfORSWOTDictVersion = 2
+)
+
+// Encode the vclock as a riak_core_vclock term
+func (v DVV) MarshalETF() ([]byte, error) {
+	acc := etfList{}
+	for _, dot := range v.vector {
+		ts := etfTuple{uint64(dot.counter), uint64(dot.timestamp) + gregorianUnixOffset}
+		acc = append(acc, etfTuple{[]byte(dot.node), ts})
+	}
+	return termToBinary(acc)
+}
+
+func (v *DVV) UnmarshalETF(data []byte) error {
+	term, err := binaryToTerm(data)
+	if err != nil {
+		return err
+	}
+	entries, err := termList(term)
+	if err != nil {
+		return err
+	}
+	acc := DVV{clock: v.clock}
+	for _, entry := range entries {
+		fields, err := termTuple(entry, 2)
+		if err != nil {
+			return err
+		}
+		node, err := termActor(fields[0])
+		if err != nil {
+			return err
+		}
+		ts_fields, err := termTuple(fields[1], 2)
+		if err != nil {
+			return err
+		}
+		counter, err := termUint(ts_fields[0], math.MaxUint32)
+		if err != nil {
+			return err
+		}
+		ts, err := termUint(ts_fields[1], math.MaxUint32+gregorianUnixOffset)
+		if err != nil {
+			return err
+		}
+		if ts < gregorianUnixOffset {
+			ts = gregorianUnixOffset
+		}
+		acc.vector = append(acc.vector, Dot{node: node, counter: uint32(counter), timestamp: uint32(ts - gregorianUnixOffset)})
+	}
+	// riak_core_vclock doesn't keep the nodes sorted
+	sort.Sort(acc)
+	if err := acc.Validate(); err != nil {
+		return err
+	}
+	*v = acc
+	return nil
+}
+
+// Encode the counter like riak_dt_gcounter to_binary
+func (counter GCounterOf[A]) MarshalETF() ([]byte, error) {
+	acc := etfList{}
+	for _, actor := range sortedKeys(counter.Counters) {
+		term, err := valueTerm(actor)
+		if err != nil {
+			return nil, err
+		}
+		acc = append(acc, etfTuple{term, uint64(counter.Counters[actor])})
+	}
+	return riakToBinary(etfGCounterTag, etfGCounterVersion, acc)
+}
+
+func (counter *GCounterOf[A]) UnmarshalETF(data []byte) error {
+	term, err := riakFromBinary(data, etfGCounterTag, etfGCounterVersion)
+	if err != nil {
+		return err
+	}
+	entries, err := termList(term)
+	if err != nil {
+		return err
+	}
+	acc := NewGCounterOf[A]()
+	for _, entry := range entries {
+		fields, err := termTuple(entry, 2)
+		if err != nil {
+			return err
+		}
+		actor, err := termValue[A](fields[0])
+		if err != nil {
+			return err
+		}
+		count, err := termUint(fields[1], math.MaxUint)
+		if err != nil {
+			return err
+		}
+		acc.Counters[actor] = uint(count)
+	}
+	*counter = acc
+	return nil
+}
+
+// Encode the counter like riak_dt_pncounter to_binary
+func (counter PNCounterOf[A]) MarshalETF() ([]byte, error) {
+	actors := counter.P.AllNode([]GCounterOf[A]{counter.N})
+	sortValues(actors)
+	acc := etfList{}
+	for _, actor := range actors {
+		term, err := valueTerm(actor)
+		if err != nil {
+			return nil, err
+		}
+		acc = append(acc, etfTuple{term, uint64(counter.P.Counters[actor]), uint64(counter.N.Counters[actor])})
+	}
+	return riakToBinary(etfPNCounterTag, etfPNCounterVersion, acc)
+}
+
+func (counter *PNCounterOf[A]) UnmarshalETF(data []byte) error {
+	term, err := riakFromBinary(data, etfPNCounterTag, etfPNCounterVersion)
+	if err != nil {
+		return err
+	}
+	entries, err := termList(term)
+	if err != nil {
+		return err
+	}
+	acc := NewPNCounterOf[A]()
+	for _, entry := range entries {
+		fields, err := termTuple(entry, 3)
+		if err != nil {
+			return err
+		}
+		actor, err := termValue[A](fields[0])
+		if err != nil {
+			return err
+		}
+		inc, err := termUint(fields[1], math.MaxUint)
+		if err != nil {
+			return err
+		}
+		dec, err := termUint(fields[2], math.MaxUint)
+		if err != nil {
+			return err
+		}
+		if inc > 0 {
+			acc.P.Counters[actor] = uint(inc)
+		}
+		if dec > 0 {
+			acc.N.Counters[actor] = uint(dec)
+		}
+	}
+	*counter = acc
+	return nil
+}
+
+// Encode the set like riak_dt_gset to_binary
+func (gset GSetOf[T]) MarshalETF() ([]byte, error) {
+	acc := etfList{}
+	for _, elem := range gset.Values() {
+		term, err := valueTerm(elem)
+		if err != nil {
+			return nil, err
+		}
+		acc = append(acc, term)
+	}
+	return riakToBinary(etfGSetTag, etfGSetVersion, acc)
+}
+
+func (gset *GSetOf[T]) UnmarshalETF(data []byte) error {
+	term, err := riakFromBinary(data, etfGSetTag, etfGSetVersion)
+	if err != nil {
+		return err
+	}
+	elems, err := termList(term)
+	if err != nil {
+		return err
+	}
+	acc := NewGSetOf[T]()
+	for _, elem := range elems {
+		value, err := termValue[T](elem)
+		if err != nil {
+			return err
+		}
+		acc.Add(value)
+	}
+	*gset = acc
+	return nil
+}
+
+// Encode the register like riak_dt_lwwreg to_binary. The actor is lost.
+func (reg LWWRegOf[V]) MarshalETF() ([]byte, error) {
+	value, err := valueTerm(reg.Value)
+	if err != nil {
+		return nil, err
+	}
+	// round down, also for timestamps before the epoch
+	ts := reg.Timestamp / nanosPerMicro
+	if reg.Timestamp%nanosPerMicro < 0 {
+		ts = ts - 1
+	}
+	return riakToBinary(etfLWWRegTag, etfLWWRegVersion, etfTuple{value, ts})
+}
+
+func (reg *LWWRegOf[V]) UnmarshalETF(data []byte) error {
+	term, err := riakFromBinary(data, etfLWWRegTag, etfLWWRegVersion)
+	if err != nil {
+		return err
+	}
+	fields, err := termTuple(term, 2)
+	if err != nil {
+		return err
+	}
+	value, err := termValue[V](fields[0])
+	if err != nil {
+		return err
+	}
+	ts, err := termInt(fields[1], math.MinInt64/nanosPerMicro, math.MaxInt64/nanosPerMicro)
+	if err != nil {
+		return err
+	}
+	*reg = LWWRegOf[V]{Value: value, Timestamp: ts * nanosPerMicro, clock: reg.clock}
+	return nil
+}
+
+// Encode the set like riak_dt_orswot to_binary. Timestamps of dots are lost.
+func (orswot ORSWOT) MarshalETF() ([]byte, error) {
+	entries := etfList{}
+	for _, elem := range orswot.Value() {
+		entries = append(entries, etfTuple{[]byte(elem), riakVclockTerm(orswot.Entries[elem])})
+	}
+	state := etfTuple{riakVclockTerm(orswot.Clock), entries, etfList{}}
+	return riakToBinary(etfORSWOTTag, etfORSWOTVersion, state)
+}
+
+func (orswot *ORSWOT) UnmarshalETF(data []byte) error {
+	version := byte(etfORSWOTVersion)
+	if len(data) > 1 && data[1] == etfORSWOTDictVersion {
+		version = etfORSWOTDictVersion
+	}
+	term, err := riakFromBinary(data, etfORSWOTTag, version)
+	if err != nil {
+		return err
+	}
+	fields, err := termTuple(term, 3)
+	if err != nil {
+		return err
+	}
+	acc := ORSWOT{Clock: DVV{clock: orswot.Clock.clock}, Entries: map[string]DVV{}}
+	if err := termRiakVclock(fields[0], &acc.Clock); err != nil {
+		return err
+	}
+	entries, err := termDict(fields[1])
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		elem, err := termValue[string](entry[0])
+		if err != nil {
+			return err
+		}
+		dots := NewDVV()
+		if err := termRiakVclock(entry[1], &dots); err != nil {
+			return err
+		}
+		acc.Entries[elem] = dots
+	}
+	if deferred, err := termDict(fields[2]); err != nil || len(deferred) > 0 {
+		return xerrors.New("orswot with deferred removes is not supported")
+	}
+	*orswot = acc
+	return nil
+}
+
+// ------------------- private functions -------------------
+
+// Terms are []byte for binaries, int64 and uint64 for integers,
+// and the types below
+type etfAtom string
+type etfTuple []interface{}
+type etfList []interface{}
+
+// An improper list, such as the [Key|Value] cells of a dict
+type etfCons struct {
+	elems etfList
+	tail  interface{}
+}
+
+const (
+	etfVersion         = 131
+	etfCompressed      = 80
+	etfSmallInteger    = 97
+	etfInteger         = 98
+	etfAtomLatin1      = 100
+	etfSmallTuple      = 104
+	etfLargeTuple      = 105
+	etfNil             = 106
+	etfString          = 107
+	etfListTag         = 108
+	etfBinary          = 109
+	etfSmallBig        = 110
+	etfSmallAtomLatin1 = 115
+	etfAtomUTF8        = 118
+	etfSmallAtomUTF8   = 119
+)
+
+// term_to_binary
+func termToBinary(term interface{}) ([]byte, error) {
+	return appendTerm([]byte{etfVersion}, term)
+}
+
+// binary_to_term, which fails on trailing bytes
+func binaryToTerm(data []byte) (interface{}, error) {
+	if len(data) < 1 || data[0] != etfVersion {
+		return nil, xerrors.New("not an external term format")
+	}
+	data = data[1:]
+	if len(data) > 0 && data[0] == etfCompressed {
+		if len(data) < 5 {
+			return nil, xerrors.New("truncated compressed term")
+		}
+		size := binary.BigEndian.Uint32(data[1:5])
+		z, err := zlib.NewReader(bytes.NewReader(data[5:]))
+		if err != nil {
+			return nil, err
+		}
+		inflated, err := io.ReadAll(io.LimitReader(z, int64(size)+1))
+		if err != nil {
+			return nil, err
+		}
+		if uint32(len(inflated)) != size {
+			return nil, xerrors.New("compressed term has a wrong size")
+		}
+		data = inflated
+	}
+	term, rest, err := decodeTerm(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, xerrors.Errorf("%d trailing bytes after term", len(rest))
+	}
+	return term, nil
+}
+
+// to_binary of riak_dt
+func riakToBinary(tag byte, version byte, term interface{}) ([]byte, error) {
+	data, err := termToBinary(term)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tag, version}, data...), nil
+}
+
+// from_binary of riak_dt
+func riakFromBinary(data []byte, tag byte, version byte) (interface{}, error) {
+	if len(data) < 2 || data[0] != tag {
+		return nil, xerrors.Errorf("expected riak_dt type tag %d", tag)
+	}
+	if data[1] != version {
+		return nil, xerrors.Errorf("unsupported version %d of riak_dt type tag %d", data[1], tag)
+	}
+	return binaryToTerm(data[2:])
+}
+
+func appendTerm(buf []byte, term interface{}) ([]byte, error) {
+	switch term := term.(type) {
+	case []byte:
+		buf = append(buf, etfBinary)
+		buf = appendUint32(buf, uint32(len(term)))
+		return append(buf, term...), nil
+	case etfAtom:
+		if len(term) > 255 {
+			return nil, xerrors.Errorf("atom %q is too long", string(term))
+		}
+		buf = append(buf, etfSmallAtomUTF8, byte(len(term)))
+		return append(buf, term...), nil
+	case int64:
+		if term >= 0 {
+			return appendUint(buf, uint64(term)), nil
+		}
+		if term >= math.MinInt32 {
+			buf = append(buf, etfInteger)
+			return appendUint32(buf, uint32(int32(term))), nil
+		}
+		buf = appendUint(buf, uint64(-term))
+		// a negative big integer has the sign byte set
+		buf[len(buf)-len(trimBig(uint64(-term)))-1] = 1
+		return buf, nil
+	case uint64:
+		return appendUint(buf, term), nil
+	case etfTuple:
+		if len(term) < 256 {
+			buf = append(buf, etfSmallTuple, byte(len(term)))
+		} else {
+			buf = append(buf, etfLargeTuple)
+			buf = appendUint32(buf, uint32(len(term)))
+		}
+		for _, elem := range term {
+			var err error
+			if buf, err = appendTerm(buf, elem); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case etfList:
+		if len(term) == 0 {
+			return append(buf, etfNil), nil
+		}
+		buf = append(buf, etfListTag)
+		buf = appendUint32(buf, uint32(len(term)))
+		for _, elem := range term {
+			var err error
+			if buf, err = appendTerm(buf, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, etfNil), nil
+	}
+	return nil, xerrors.Errorf("%T can't be encoded as a term", term)
+}
+
+// Append a non negative integer in the smallest encoding
+func appendUint(buf []byte, x uint64) []byte {
+	if x < 256 {
+		return append(buf, etfSmallInteger, byte(x))
+	}
+	if x <= math.MaxInt32 {
+		buf = append(buf, etfInteger)
+		return appendUint32(buf, uint32(x))
+	}
+	digits := trimBig(x)
+	buf = append(buf, etfSmallBig, byte(len(digits)), 0)
+	return append(buf, digits...)
+}
+
+// The little endian bytes of x without trailing zeros
+func trimBig(x uint64) []byte {
+	digits := make([]byte, 8)
+	binary.LittleEndian.PutUint64(digits, x)
+	for len(digits) > 1 && digits[len(digits)-1] == 0 {
+		digits = digits[:len(digits)-1]
+	}
+	return digits
+}
+
+func decodeTerm(data []byte) (interface{}, []byte, error) {
+	truncated := xerrors.New("truncated term")
+	if len(data) < 1 {
+		return nil, nil, truncated
+	}
+	tag, data := data[0], data[1:]
+	switch tag {
+	case etfSmallInteger:
+		if len(data) < 1 {
+			return nil, nil, truncated
+		}
+		return int64(data[0]), data[1:], nil
+	case etfInteger:
+		if len(data) < 4 {
+			return nil, nil, truncated
+		}
+		return int64(int32(binary.BigEndian.Uint32(data))), data[4:], nil
+	case etfSmallBig:
+		if len(data) < 2 || len(data) < 2+int(data[0]) {
+			return nil, nil, truncated
+		}
+		n, sign, digits := int(data[0]), data[1], data[2:2+int(data[0])]
+		if n > 8 {
+			return nil, nil, xerrors.New("integer overflows 64 bits")
+		}
+		var x uint64
+		for i := n - 1; i >= 0; i-- {
+			x = x<<8 | uint64(digits[i])
+		}
+		if sign == 0 {
+			if x <= math.MaxInt64 {
+				return int64(x), data[2+n:], nil
+			}
+			return x, data[2+n:], nil
+		}
+		if x > 1<<63 {
+			return nil, nil, xerrors.New("integer overflows 64 bits")
+		}
+		return -int64(x), data[2+n:], nil
+	case etfAtomLatin1, etfAtomUTF8:
+		if len(data) < 2 || len(data) < 2+int(binary.BigEndian.Uint16(data)) {
+			return nil, nil, truncated
+		}
+		n := int(binary.BigEndian.Uint16(data))
+		return etfAtom(data[2 : 2+n]), data[2+n:], nil
+	case etfSmallAtomLatin1, etfSmallAtomUTF8:
+		if len(data) < 1 || len(data) < 1+int(data[0]) {
+			return nil, nil, truncated
+		}
+		n := int(data[0])
+		return etfAtom(data[1 : 1+n]), data[1+n:], nil
+	case etfBinary:
+		if len(data) < 4 || uint64(len(data)-4) < uint64(binary.BigEndian.Uint32(data)) {
+			return nil, nil, truncated
+		}
+		n := int(binary.BigEndian.Uint32(data))
+		return append([]byte{}, data[4:4+n]...), data[4+n:], nil
+	case etfSmallTuple, etfLargeTuple:
+		var n int
+		if tag == etfSmallTuple {
+			if len(data) < 1 {
+				return nil, nil, truncated
+			}
+			n, data = int(data[0]), data[1:]
+		} else {
+			if len(data) < 4 {
+				return nil, nil, truncated
+			}
+			n, data = int(binary.BigEndian.Uint32(data)), data[4:]
+		}
+		if n > len(data) {
+			return nil, nil, truncated
+		}
+		acc := etfTuple{}
+		for i := 0; i < n; i++ {
+			elem, rest, err := decodeTerm(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			acc, data = append(acc, elem), rest
+		}
+		return acc, data, nil
+	case etfNil:
+		return etfList{}, data, nil
+	case etfString:
+		if len(data) < 2 || len(data) < 2+int(binary.BigEndian.Uint16(data)) {
+			return nil, nil, truncated
+		}
+		n := int(binary.BigEndian.Uint16(data))
+		acc := etfList{}
+		for _, b := range data[2 : 2+n] {
+			acc = append(acc, int64(b))
+		}
+		return acc, data[2+n:], nil
+	case etfListTag:
+		if len(data) < 4 || uint64(len(data)-4) < uint64(binary.BigEndian.Uint32(data)) {
+			return nil, nil, truncated
+		}
+		n := int(binary.BigEndian.Uint32(data))
+		data = data[4:]
+		acc := etfList{}
+		for i := 0; i < n; i++ {
+			elem, rest, err := decodeTerm(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			acc, data = append(acc, elem), rest
+		}
+		tail, rest, err := decodeTerm(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tail, ok := tail.(etfList); ok && len(tail) == 0 {
+			return acc, rest, nil
+		}
+		return etfCons{elems: acc, tail: tail}, rest, nil
+	}
+	return nil, nil, xerrors.Errorf("unsupported term tag %d", tag)
+}
+
+func termList(term interface{}) (etfList, error) {
+	if list, ok := term.(etfList); ok {
+		return list, nil
+	}
+	return nil, xerrors.Errorf("expected a list but got %T", term)
+}
+
+// The key value pairs of an orddict, or of a dict of the dict module,
+// which is the record {dict, Size, N, MaxN, BSO, ExpSize, ConSize,
+// Empty, Segs} with the [Key|Value] cells in the buckets of the
+// segments
+func termDict(term interface{}) ([]etfTuple, error) {
+	var acc []etfTuple
+	if list, ok := term.(etfList); ok {
+		for _, entry := range list {
+			pair, err := termTuple(entry, 2)
+			if err != nil {
+				return nil, err
+			}
+			acc = append(acc, pair)
+		}
+		return acc, nil
+	}
+	record, ok := term.(etfTuple)
+	if !ok || len(record) != 9 || record[0] != etfAtom("dict") {
+		return nil, xerrors.Errorf("expected an orddict or a dict but got %v", term)
+	}
+	segs, ok := record[8].(etfTuple)
+	if !ok {
+		return nil, xerrors.New("dict has no segments")
+	}
+	for _, seg := range segs {
+		buckets, ok := seg.(etfTuple)
+		if !ok {
+			return nil, xerrors.New("dict has a broken segment")
+		}
+		for _, bucket := range buckets {
+			cells, err := termList(bucket)
+			if err != nil {
+				return nil, err
+			}
+			for _, cell := range cells {
+				// [Key|Value] is a proper list when the value is a list
+				switch cell := cell.(type) {
+				case etfList:
+					if len(cell) > 0 {
+						acc = append(acc, etfTuple{cell[0], cell[1:]})
+						continue
+					}
+				case etfCons:
+					if len(cell.elems) == 1 {
+						acc = append(acc, etfTuple{cell.elems[0], cell.tail})
+						continue
+					}
+					if len(cell.elems) > 1 {
+						acc = append(acc, etfTuple{cell.elems[0], etfCons{elems: cell.elems[1:], tail: cell.tail}})
+						continue
+					}
+				}
+				return nil, xerrors.Errorf("dict has a broken cell %v", cell)
+			}
+		}
+	}
+	return acc, nil
+}
+
+func termTuple(term interface{}, arity int) (etfTuple, error) {
+	if tuple, ok := term.(etfTuple); ok && len(tuple) == arity {
+		return tuple, nil
+	}
+	return nil, xerrors.Errorf("expected a tuple of %d but got %v", arity, term)
+}
+
+func termUint(term interface{}, max uint64) (uint64, error) {
+	var x uint64
+	switch term := term.(type) {
+	case int64:
+		if term < 0 {
+			return 0, xerrors.Errorf("expected a non negative integer but got %d", term)
+		}
+		x = uint64(term)
+	case uint64:
+		x = term
+	default:
+		return 0, xerrors.Errorf("expected an integer but got %T", term)
+	}
+	if x > max {
+		return 0, xerrors.Errorf("integer %d is out of range", x)
+	}
+	return x, nil
+}
+
+// Actors of riak are binaries, or atoms for node names
+func termActor(term interface{}) (string, error) {
+	if atom, ok := term.(etfAtom); ok {
+		return string(atom), nil
+	}
+	return termValue[string](term)
+}
+
+// The term of a value, a binary for strings and byte slices
+// and an integer for integers
+func valueTerm[V any](value V) (interface{}, error) {
+	switch value := any(value).(type) {
+	case string:
+		return []byte(value), nil
+	case []byte:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case uint:
+		return uint64(value), nil
+	case uint32:
+		return uint64(value), nil
+	case uint64:
+		return value, nil
+	}
+	return nil, xerrors.Errorf("%T can't be encoded as a term", value)
+}
+
+func termValue[V any](term interface{}) (V, error) {
+	var acc V
+	var err error
+	switch value := any(&acc).(type) {
+	case *string:
+		var b []byte
+		if b, err = termBinary(term); err == nil {
+			*value = string(b)
+		}
+	case *[]byte:
+		*value, err = termBinary(term)
+	case *int:
+		var x int64
+		if x, err = termInt(term, math.MinInt, math.MaxInt); err == nil {
+			*value = int(x)
+		}
+	case *int32:
+		var x int64
+		if x, err = termInt(term, math.MinInt32, math.MaxInt32); err == nil {
+			*value = int32(x)
+		}
+	case *int64:
+		*value, err = termInt(term, math.MinInt64, math.MaxInt64)
+	case *uint:
+		var x uint64
+		if x, err = termUint(term, math.MaxUint); err == nil {
+			*value = uint(x)
+		}
+	case *uint32:
+		var x uint64
+		if x, err = termUint(term, math.MaxUint32); err == nil {
+			*value = uint32(x)
+		}
+	case *uint64:
+		*value, err = termUint(term, math.MaxUint64)
+	default:
+		err = xerrors.Errorf("%T can't be decoded from a term", acc)
+	}
+	return acc, err
+}
+
+func termBinary(term interface{}) ([]byte, error) {
+	if b, ok := term.([]byte); ok {
+		return b, nil
+	}
+	return nil, xerrors.Errorf("expected a binary but got %T", term)
+}
+
+func termInt(term interface{}, min int64, max int64) (int64, error) {
+	x, ok := term.(int64)
+	if !ok || x < min || x > max {
+		return 0, xerrors.Errorf("expected an integer in range but got %v", term)
+	}
+	return x, nil
+}
+
+// The dots of the vclock as a riak_dt_vclock term, without timestamps
+func riakVclockTerm(v DVV) etfList {
+	acc := etfList{}
+	for _, dot := range v.vector {
+		acc = append(acc, etfTuple{[]byte(dot.node), uint64(dot.counter)})
+	}
+	return acc
+}
+
+func termRiakVclock(term interface{}, v *DVV) error {
+	entries, err := termList(term)
+	if err != nil {
+		return err
+	}
+	acc := DVV{clock: v.clock}
+	for _, entry := range entries {
+		fields, err := termTuple(entry, 2)
+		if err != nil {
+			return err
+		}
+		node, err := termActor(fields[0])
+		if err != nil {
+			return err
+		}
+		counter, err := termUint(fields[1], math.MaxUint32)
+		if err != nil {
+			return err
+		}
+		acc.vector = append(acc.vector, Dot{node: node, counter: uint32(counter)})
+	}
+	sort.Sort(acc)
+	if err := acc.Validate(); err != nil {
+		return err
+	}
+	*v = acc
+	return nil
+}
+
+// Append x as 4 big endian bytes
+func appendUint32(buf []byte, x uint32) []byte {
+	return append(buf, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// Enable-wins and disable-wins flags
+// based on riak_dt_od_flag
+
+package dt
+
+// An enable-wins flag, starting disabled. Enabling adds a dot, and
+// disabling drops the observed dots, so a concurrent enable survives.
+type EWFlag struct {
+	Clock DVV
+	Dots  DVV
+}
+
+// A disable-wins flag, starting enabled. Disabling adds a dot, and
+// enabling drops the observed dots, so a concurrent disable survives.
+type DWFlag struct {
+	Clock DVV
+	Dots  DVV
+}
+
+// Create a new ewflag
+func NewEWFlag() EWFlag {
+	return EWFlag{Clock: NewDVV(), Dots: NewDVV()}
+}
+
+// Create a new ewflag whose dots read timestamps from the clock
+func NewEWFlagWithClock(clock Clock) EWFlag {
+	return EWFlag{Clock: NewDVVWithClock(clock), Dots: NewDVV()}
+}
+
+// true if the flag is enabled
+func (flag EWFlag) Value() bool {
+	return flag.Dots.Len() > 0
+}
+
+// Enable the flag with a new dot of the actor
+func (flag *EWFlag) Enable(actor string) {
+	flag.Dots = newFlagDot(&flag.Clock, actor)
+}
+
+// Disable the flag
+func (flag *EWFlag) Disable() {
+	flag.Dots = NewDVV()
+}
+
+// Merge two ewflag to a single ewflag
+func (flag_a *EWFlag) Merge(flag_b EWFlag) {
+	flag_a.Dots = mergeDots(flag_a.Dots, flag_a.Clock, flag_b.Dots, flag_b.Clock)
+	flag_a.Clock.Merge([]DVV{flag_b.Clock})
+}
+
+// Compare two ewflag for equality
+func (flag_a EWFlag) Equal(flag_b EWFlag) bool {
+	return flag_a.Clock.equal(flag_b.Clock) && flag_a.Dots.equal(flag_b.Dots)
+}
+
+// Return a copy of the flag
+func (flag EWFlag) Clone() EWFlag {
+	return flag
+}
+
+// Create a new dwflag
+func NewDWFlag() DWFlag {
+	return DWFlag{Clock: NewDVV(), Dots: NewDVV()}
+}
+
+// Create a new dwflag whose dots read timestamps from the clock
+func NewDWFlagWithClock(clock Clock) DWFlag {
+	return DWFlag{Clock: NewDVVWithClock(clock), Dots: NewDVV()}
+}
+
+// true if the flag is enabled
+func (flag DWFlag) Value() bool {
+	return flag.Dots.Len() == 0
+}
+
+// Enable the flag
+func (flag *DWFlag) Enable() {
+	flag.Dots = NewDVV()
+}
+
+// Disable the flag with a new dot of the actor
+func (flag *DWFlag) Disable(actor string) {
+	flag.Dots = newFlagDot(&flag.Clock, actor)
+}
+
+// Merge two dwflag to a single dwflag
+func (flag_a *DWFlag) Merge(flag_b DWFlag) {
+	flag_a.Dots = mergeDots(flag_a.Dots, flag_a.Clock, flag_b.Dots, flag_b.Clock)
+	flag_a.Clock.Merge([]DVV{flag_b.Clock})
+}
+
+// Compare two dwflag for equality
+func (flag_a DWFlag) Equal(flag_b DWFlag) bool {
+	return flag_a.Clock.equal(flag_b.Clock) && flag_a.Dots.equal(flag_b.Dots)
+}
+
+// Return a copy of the flag
+func (flag DWFlag) Clone() DWFlag {
+	return flag
+}
+
+// ------------------- private functions -------------------
+
+// the dots of a flag after a new dot of the actor, which
+// replaces every dot the flag had observed
+func newFlagDot(clock *DVV, actor string) DVV {
+	clock.Increment(actor)
+	dot, _ := clock.GetDot(actor)
+	dots := NewDVV()
+	dots.vector = []Dot{*dot}
+	return dots
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"testing"
+)
+
+func TestEWFlag(t *testing.T) {
+	flag := NewEWFlag()
+	if flag.Value() {
+		t.Error("ewflag should start disabled")
+	}
+
+	flag.Enable("a")
+	if !flag.Value() {
+		t.Error("ewflag should be enabled")
+	}
+
+	flag.Disable()
+	if flag.Value() {
+		t.Error("ewflag should be disabled")
+	}
+}
+
+func TestEWFlagMerge(t *testing.T) {
+	flag1 := NewEWFlag()
+	flag1.Enable("a")
+
+	flag2 := NewEWFlag()
+	flag2.Merge(flag1)
+	if !flag2.Value() {
+		t.Error("flag2 should be enabled")
+	}
+
+	// a disables while b concurrently enables
+	flag1.Disable()
+	flag2.Enable("b")
+
+	flag1.Merge(flag2)
+	flag2.Merge(flag1)
+	if !flag1.Value() || !flag1.Equal(flag2) {
+		t.Errorf("concurrent enable should win %#v %#v", flag1, flag2)
+	}
+
+	// a disable which has seen every enable wins
+	flag1.Disable()
+	flag2.Merge(flag1)
+	if flag2.Value() {
+		t.Errorf("observed disable should win %#v", flag2)
+	}
+}
+
+func TestDWFlag(t *testing.T) {
+	flag := NewDWFlag()
+	if !flag.Value() {
+		t.Error("dwflag should start enabled")
+	}
+
+	flag.Disable("a")
+	if flag.Value() {
+		t.Error("dwflag should be disabled")
+	}
+
+	flag.Enable()
+	if !flag.Value() {
+		t.Error("dwflag should be enabled")
+	}
+}
+
+func TestDWFlagMerge(t *testing.T) {
+	flag1 := NewDWFlag()
+	flag1.Disable("a")
+
+	flag2 := NewDWFlag()
+	flag2.Merge(flag1)
+	if flag2.Value() {
+		t.Error("flag2 should be disabled")
+	}
+
+	// a enables while b concurrently disables
+	flag1.Enable()
+	flag2.Disable("b")
+
+	flag1.Merge(flag2)
+	flag2.Merge(flag1)
+	if flag1.Value() || !flag1.Equal(flag2) {
+		t.Errorf("concurrent disable should win %#v %#v", flag1, flag2)
+	}
+
+	// an enable which has seen every disable wins
+	flag1.Enable()
+	flag2.Merge(flag1)
+	if !flag2.Value() {
+		t.Errorf("observed enable should win %#v", flag2)
+	}
+}
@@ -3,22 +3,37 @@
 package dt
 
 import (
+	"math"
 	"reflect"
+
+	"golang.org/x/xerrors"
 )
 
-type GCounter struct {
-	Counters map[string]uint
+// Returned when a counter would wrap around
+var ErrOverflow = xerrors.New("counter overflow")
+
+// A gcounter whose actors are identified by values of type A
+type GCounterOf[A comparable] struct {
+	Counters map[A]uint
 }
 
+// A gcounter whose actors are identified by strings
+type GCounter = GCounterOf[string]
+
 // Create a new gcounter
 func NewGCounter() GCounter {
-	c := map[string]uint{}
-	counter := GCounter{Counters: c}
+	return NewGCounterOf[string]()
+}
+
+// Create a new gcounter whose actors are identified by values of type A
+func NewGCounterOf[A comparable]() GCounterOf[A] {
+	c := map[A]uint{}
+	counter := GCounterOf[A]{Counters: c}
 	return counter
 }
 
 // The single total value of a gcounter
-func (counter GCounter) Value() uint {
+func (counter GCounterOf[A]) Value() uint {
 	var acc uint = 0
 	for _, v := range counter.Counters {
 		acc = acc + v
@@ -26,38 +41,61 @@ func (counter GCounter) Value() uint {
 	return acc
 }
 
+// The single total value of a gcounter, or an error if the sum overflows
+func (counter GCounterOf[A]) ValueChecked() (uint, error) {
+	var acc uint = 0
+	for _, v := range counter.Counters {
+		if acc > math.MaxUint-v {
+			return 0, ErrOverflow
+		}
+		acc = acc + v
+	}
+	return acc, nil
+}
+
 // Compare two counter for equality
-func (counter_a GCounter) Equal(counter_b GCounter) bool {
+func (counter_a GCounterOf[A]) Equal(counter_b GCounterOf[A]) bool {
 	return reflect.DeepEqual(counter_a, counter_b)
 }
 
-// Combine all counters in the input list uinto a map
-func (counter_a *GCounter) Merge(counters []GCounter) {
-	acc := map[string]uint{}
-	nodes := counter_a.AllNode(counters)
-	for i := 0; i < len(nodes); i++ {
-		node := nodes[i]
-		for j := 0; j < len(counters); j++ {
-			counter_b := counters[j]
-			cnt_a := counter_a.Counters[node]
-			cnt_b := counter_b.Counters[node]
-			cnt_max := cnt_a
-			if cnt_max < cnt_b {
-				cnt_max = cnt_b
+// Return a copy of the counter
+func (counter GCounterOf[A]) Clone() GCounterOf[A] {
+	acc := NewGCounterOf[A]()
+	for node, v := range counter.Counters {
+		acc.Counters[node] = v
+	}
+	return acc
+}
+
+// Combine all counters in the input list uinto a map, taking the
+// pointwise maximum of every node in a single pass over the inputs
+func (counter_a *GCounterOf[A]) Merge(counters []GCounterOf[A]) {
+	acc := make(map[A]uint, len(counter_a.Counters))
+	for node, cnt := range counter_a.Counters {
+		acc[node] = cnt
+	}
+	for _, counter_b := range counters {
+		for node, cnt_b := range counter_b.Counters {
+			if cnt_a, ok := acc[node]; !ok || cnt_a < cnt_b {
+				acc[node] = cnt_b
 			}
-			acc[node] = cnt_max
 		}
 	}
 	counter_a.Counters = acc
 }
 
+// Combine the other counter into the counter
+func (counter_a *GCounterOf[A]) MergeWith(counter_b GCounterOf[A]) {
+	counter_a.Merge([]GCounterOf[A]{counter_b})
+}
+
 // increment counter for the node by 1
-func (counter *GCounter) Increment(node string) {
+func (counter *GCounterOf[A]) Increment(node A) {
 	counter.IncrementBy(node, 1)
 }
 
 // perform the increment
-func (counter *GCounter) IncrementBy(node string, amount uint) {
+func (counter *GCounterOf[A]) IncrementBy(node A, amount uint) {
 	if val, ok := counter.Counters[node]; ok {
 		counter.Counters[node] = val + amount
 		return
@@ -65,11 +103,27 @@ func (counter *GCounter) IncrementBy(node string, amount uint) {
 	counter.Counters[node] = amount
 }
 
+// increment counter for the node by 1, unless it would overflow
+func (counter *GCounterOf[A]) IncrementChecked(node A) error {
+	return counter.IncrementByChecked(node, 1)
+}
+
+// perform the increment, or leave the counter as is and return
+// ErrOverflow if the count of the node would wrap around
+func (counter *GCounterOf[A]) IncrementByChecked(node A, amount uint) error {
+	val := counter.Counters[node]
+	if val > math.MaxUint-amount {
+		return ErrOverflow
+	}
+	counter.Counters[node] = val + amount
+	return nil
+}
+
 // Returns the list of all nodes that have ever incremneted counter
-func (counter GCounter) AllNode(counters []GCounter) []string {
+func (counter GCounterOf[A]) AllNode(counters []GCounterOf[A]) []A {
 	counters = append(counters, counter)
-	hset := map[string]bool{}
-	var nodes []string
+	hset := map[A]bool{}
+	var nodes []A
 	for i := 0; i < len(counters); i++ {
 		for node := range counters[i].Counters {
 			hset[node] = true
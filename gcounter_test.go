@@ -4,6 +4,7 @@
 package dt
 
 import (
+	"math"
 	"testing"
 )
 
@@ -111,3 +112,102 @@ func TestGCounterUsage(t *testing.T) {
 		t.Errorf("Counter should be same with expected %#v", c1)
 	}
 }
+
+func TestGCounterMultiMerge(t *testing.T) {
+	c1 := NewGCounter()
+	c2 := NewGCounter()
+	c3 := NewGCounter()
+	c4 := NewGCounter()
+	c1.Counters = map[string]uint{"1": 1, "2": 5}
+	c2.Counters = map[string]uint{"1": 4, "3": 3}
+	c3.Counters = map[string]uint{"2": 2, "3": 6, "4": 1}
+	c4.Counters = map[string]uint{"1": 2}
+	c1.Merge([]GCounter{c2, c3, c4})
+
+	expected := NewGCounter()
+	expected.Counters = map[string]uint{"1": 4, "2": 5, "3": 6, "4": 1}
+
+	if !c1.Equal(expected) {
+		t.Errorf("Counter should be same with expected %#v", c1)
+	}
+
+	if c2.Counters["1"] != 4 || len(c2.Counters) != 2 {
+		t.Errorf("inputs shouldn't be changed %#v", c2)
+	}
+}
+
+func TestGCounterMergeEmpty(t *testing.T) {
+	c1 := NewGCounter()
+	c1.Counters = map[string]uint{"1": 1}
+	c1.Merge([]GCounter{})
+
+	expected := NewGCounter()
+	expected.Counters = map[string]uint{"1": 1}
+
+	if !c1.Equal(expected) {
+		t.Errorf("Counter should be same with expected %#v", c1)
+	}
+}
+
+func TestGCounterMergeWith(t *testing.T) {
+	c1 := NewGCounter()
+	c2 := NewGCounter()
+	c1.Counters = map[string]uint{"1": 1, "2": 2}
+	c2.Counters = map[string]uint{"2": 3}
+	c1.MergeWith(c2)
+
+	expected := NewGCounter()
+	expected.Counters = map[string]uint{"1": 1, "2": 3}
+
+	if !c1.Equal(expected) {
+		t.Errorf("Counter should be same with expected %#v", c1)
+	}
+}
+
+func TestGCounterIncrementChecked(t *testing.T) {
+	c1 := NewGCounter()
+
+	if err := c1.IncrementByChecked("a", math.MaxUint-1); err != nil {
+		t.Errorf("increment shouldn't overflow %v", err)
+	}
+
+	if err := c1.IncrementChecked("a"); err != nil {
+		t.Errorf("increment shouldn't overflow %v", err)
+	}
+
+	if err := c1.IncrementChecked("a"); err != ErrOverflow {
+		t.Errorf("increment should overflow %v", err)
+	}
+
+	if c1.Counters["a"] != math.MaxUint {
+		t.Errorf("overflowed increment shouldn't change counter %#v", c1)
+	}
+}
+
+func TestGCounterValueChecked(t *testing.T) {
+	c1 := NewGCounter()
+	c1.Counters = map[string]uint{"a": 1, "b": 13, "c": 1}
+
+	if val, err := c1.ValueChecked(); val != 15 || err != nil {
+		t.Errorf("Counter should be 15 but %d %v", val, err)
+	}
+
+	c1.Counters = map[string]uint{"a": math.MaxUint, "b": 1}
+
+	if _, err := c1.ValueChecked(); err != ErrOverflow {
+		t.Errorf("Counter value should overflow %v", err)
+	}
+}
+
+func TestGCounterOf(t *testing.T) {
+	c1 := NewGCounterOf[uint64]()
+	c1.IncrementBy(1, 2)
+	c2 := NewGCounterOf[uint64]()
+	c2.IncrementBy(2, 3)
+	c2.Increment(1)
+
+	c1.MergeWith(c2)
+	if c1.Value() != 5 {
+		t.Errorf("Counter should be 5 but %d", c1.Value())
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/shun159/dt
 
-go 1.17
+go 1.18
 
-require golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+require golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1
@@ -6,32 +6,42 @@ import (
 	"reflect"
 )
 
-type GSet struct {
-	Set map[string]bool
+// A gset of elements of type T
+type GSetOf[T comparable] struct {
+	Set map[T]bool
 }
 
+// A gset of strings
+type GSet = GSetOf[string]
+
 // Create a new gset
 func NewGSet() GSet {
-	s := map[string]bool{}
-	return GSet{Set: s}
+	return NewGSetOf[string]()
+}
+
+// Create a new gset of elements of type T
+func NewGSetOf[T comparable]() GSetOf[T] {
+	s := map[T]bool{}
+	return GSetOf[T]{Set: s}
 }
 
-// Returns a set of value.
-func (gset *GSet) Values() []string {
-	acc := []string{}
+// Returns a set of value, sorted.
+func (gset *GSetOf[T]) Values() []T {
+	acc := []T{}
 	for v, _ := range gset.Set {
 		acc = append(acc, v)
 	}
+	sortValues(acc)
 	return acc
 }
 
 // append an element to the set
-func (gset *GSet) Add(elem string) {
+func (gset *GSetOf[T]) Add(elem T) {
 	gset.Set[elem] = true
 }
 
 // return true if the element exists in the set
-func (gset GSet) Exists(elem string) bool {
+func (gset GSetOf[T]) Exists(elem T) bool {
 	if _, ok := gset.Set[elem]; ok {
 		return true
 	}
@@ -39,6 +49,29 @@ func (gset GSet) Exists(elem string) bool {
 }
 
 // Compair Two set for equality
-func (gset_a GSet) Equal(gset_b GSet) bool {
+func (gset_a GSetOf[T]) Equal(gset_b GSetOf[T]) bool {
 	return reflect.DeepEqual(gset_a.Set, gset_b.Set)
 }
+
+// Return a copy of the set
+func (gset GSetOf[T]) Clone() GSetOf[T] {
+	acc := NewGSetOf[T]()
+	for elem := range gset.Set {
+		acc.Set[elem] = true
+	}
+	return acc
+}
+
+// Combine all sets in the input list into the set by union
+func (gset_a *GSetOf[T]) Merge(gsets []GSetOf[T]) {
+	for _, gset_b := range gsets {
+		for elem := range gset_b.Set {
+			gset_a.Set[elem] = true
+		}
+	}
+}
+
+// Combine the other set into the set
+func (gset_a *GSetOf[T]) MergeWith(gset_b GSetOf[T]) {
+	gset_a.Merge([]GSetOf[T]{gset_b})
+}
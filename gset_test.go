@@ -4,6 +4,7 @@
 package dt
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -53,3 +54,69 @@ func TestGSetEqual(t *testing.T) {
 		t.Errorf("gset shouldn't be equal with gset2")
 	}
 }
+
+func TestGSetMerge(t *testing.T) {
+	gset1 := NewGSet()
+	gset1.Add("value1")
+
+	gset2 := NewGSet()
+	gset2.Add("value2")
+
+	gset3 := NewGSet()
+	gset3.Add("value1")
+	gset3.Add("value3")
+
+	gset1.Merge([]GSet{gset2, gset3})
+
+	expected := NewGSet()
+	expected.Add("value1")
+	expected.Add("value2")
+	expected.Add("value3")
+
+	if !gset1.Equal(expected) {
+		t.Errorf("gset1 should be equal with expected %#v", gset1)
+	}
+
+	gset2.MergeWith(gset1)
+	if !gset2.Equal(expected) {
+		t.Errorf("gset2 should be equal with expected %#v", gset2)
+	}
+}
+
+func TestGSetOf(t *testing.T) {
+	type point struct{ x, y int }
+	gset1 := NewGSetOf[point]()
+	gset1.Add(point{1, 2})
+	gset2 := NewGSetOf[point]()
+	gset2.Add(point{0, 5})
+	gset2.Add(point{1, 2})
+
+	gset1.MergeWith(gset2)
+	expected := []point{{0, 5}, {1, 2}}
+	if !reflect.DeepEqual(gset1.Values(), expected) {
+		t.Errorf("gset.values should be %#v but %#v", expected, gset1.Values())
+	}
+
+	ints := NewGSetOf[int]()
+	ints.Add(10)
+	ints.Add(9)
+	if !reflect.DeepEqual(ints.Values(), []int{9, 10}) {
+		t.Errorf("ints should be sorted %#v", ints.Values())
+	}
+
+	int32s := NewGSetOf[int32]()
+	for _, elem := range []int32{9, 10, -1, -2} {
+		int32s.Add(elem)
+	}
+	if !reflect.DeepEqual(int32s.Values(), []int32{-2, -1, 9, 10}) {
+		t.Errorf("int32s should be sorted %#v", int32s.Values())
+	}
+
+	float32s := NewGSetOf[float32]()
+	for _, elem := range []float32{2.5, 10, -1} {
+		float32s.Add(elem)
+	}
+	if !reflect.DeepEqual(float32s.Values(), []float32{-1, 2.5, 10}) {
+		t.Errorf("float32s should be sorted %#v", float32s.Values())
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// Hybrid logical clock implementation
+// based on https://cse.buffalo.edu/tech-reports/2014-04.pdf
+
+package dt
+
+import (
+	"math"
+	"time"
+)
+
+// Physical time in milliseconds, and a logical counter that orders
+// events within the same millisecond
+type HLCTimestamp struct {
+	WallTime int64
+	Logical  uint16
+}
+
+// A hybrid logical clock. Its timestamps follow the physical clock,
+// but never go backwards and always come after every timestamp
+// the clock has seen, even if the physical clocks are skewed.
+type HLC struct {
+	last  HLCTimestamp
+	clock Clock
+}
+
+// Instantiate a new HLC reading the system clock
+func NewHLC() *HLC {
+	return &HLC{}
+}
+
+// Instantiate a new HLC reading the physical time from the clock
+func NewHLCWithClock(clock Clock) *HLC {
+	return &HLC{clock: clock}
+}
+
+// Return a timestamp for a local or send event
+func (c *HLC) Now() HLCTimestamp {
+	pt := c.now()
+	if pt > c.last.WallTime {
+		c.last = HLCTimestamp{WallTime: pt}
+	} else {
+		c.last = c.last.next(c.last.Logical)
+	}
+	return c.last
+}
+
+// Return a timestamp for the receipt of the remote timestamp,
+// which comes after both the remote and every local timestamp
+func (c *HLC) Update(remote HLCTimestamp) HLCTimestamp {
+	pt := c.now()
+	if pt > c.last.WallTime && pt > remote.WallTime {
+		c.last = HLCTimestamp{WallTime: pt}
+	} else if c.last.WallTime == remote.WallTime {
+		logical := c.last.Logical
+		if remote.Logical > logical {
+			logical = remote.Logical
+		}
+		c.last = c.last.next(logical)
+	} else if c.last.WallTime > remote.WallTime {
+		c.last = c.last.next(c.last.Logical)
+	} else {
+		c.last = remote.next(remote.Logical)
+	}
+	return c.last
+}
+
+// Encode the timestamp in 64 bits, the wall time in the upper 48 bits
+// and the logical counter in the lower 16. Encoded timestamps are
+// ordered like the timestamps, so they can be used as LWWReg timestamps.
+func (t HLCTimestamp) Encode() int64 {
+	return t.WallTime<<16 | int64(t.Logical)
+}
+
+// Decode a timestamp encoded by Encode
+func DecodeHLCTimestamp(v int64) HLCTimestamp {
+	return HLCTimestamp{WallTime: v >> 16, Logical: uint16(v & math.MaxUint16)}
+}
+
+// true if the timestamp t is before the timestamp u
+func (t HLCTimestamp) Less(u HLCTimestamp) bool {
+	if t.WallTime != u.WallTime {
+		return t.WallTime < u.WallTime
+	}
+	return t.Logical < u.Logical
+}
+
+// ------------------- private functions -------------------
+
+// The physical time in milliseconds
+func (c *HLC) now() int64 {
+	return clockOrSystem(c.clock).Now().UnixNano() / int64(time.Millisecond)
+}
+
+// the timestamp after logical in the same millisecond, or the first
+// of the next millisecond when the logical counter is exhausted
+func (t HLCTimestamp) next(logical uint16) HLCTimestamp {
+	if logical == math.MaxUint16 {
+		return HLCTimestamp{WallTime: t.WallTime + 1}
+	}
+	return HLCTimestamp{WallTime: t.WallTime, Logical: logical + 1}
+}
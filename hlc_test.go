@@ -0,0 +1,124 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// a hlc whose physical clock is pt milliseconds
+func newTestHLC(pt *int64) *HLC {
+	return NewHLCWithClock(millisClock{pt})
+}
+
+type millisClock struct {
+	pt *int64
+}
+
+func (c millisClock) Now() time.Time {
+	return time.Unix(0, *c.pt*int64(time.Millisecond))
+}
+
+func TestHLCNow(t *testing.T) {
+	pt := int64(10)
+	c := newTestHLC(&pt)
+
+	if ts := c.Now(); ts != (HLCTimestamp{WallTime: 10}) {
+		t.Errorf("timestamp should be 10.0 but %#v", ts)
+	}
+
+	if ts := c.Now(); ts != (HLCTimestamp{WallTime: 10, Logical: 1}) {
+		t.Errorf("timestamp should be 10.1 but %#v", ts)
+	}
+
+	// physical clock going backwards
+	pt = 5
+	if ts := c.Now(); ts != (HLCTimestamp{WallTime: 10, Logical: 2}) {
+		t.Errorf("timestamp should be 10.2 but %#v", ts)
+	}
+
+	pt = 11
+	if ts := c.Now(); ts != (HLCTimestamp{WallTime: 11}) {
+		t.Errorf("timestamp should be 11.0 but %#v", ts)
+	}
+}
+
+func TestHLCUpdate(t *testing.T) {
+	pt := int64(10)
+	c := newTestHLC(&pt)
+	c.Now()
+
+	cases := []struct {
+		pt       int64
+		remote   HLCTimestamp
+		expected HLCTimestamp
+	}{
+		// remote ahead of the local clocks
+		{10, HLCTimestamp{WallTime: 20, Logical: 3}, HLCTimestamp{WallTime: 20, Logical: 4}},
+		// remote at the same wall time
+		{10, HLCTimestamp{WallTime: 20, Logical: 7}, HLCTimestamp{WallTime: 20, Logical: 8}},
+		// remote behind
+		{10, HLCTimestamp{WallTime: 15, Logical: 9}, HLCTimestamp{WallTime: 20, Logical: 9}},
+		// physical clock ahead of both
+		{30, HLCTimestamp{WallTime: 25}, HLCTimestamp{WallTime: 30}},
+	}
+	for i, cs := range cases {
+		pt = cs.pt
+		if ts := c.Update(cs.remote); ts != cs.expected {
+			t.Errorf("case %d: timestamp should be %#v but %#v", i, cs.expected, ts)
+		}
+	}
+}
+
+func TestHLCLogicalOverflow(t *testing.T) {
+	pt := int64(10)
+	c := newTestHLC(&pt)
+	c.last = HLCTimestamp{WallTime: 10, Logical: math.MaxUint16}
+
+	if ts := c.Now(); ts != (HLCTimestamp{WallTime: 11}) {
+		t.Errorf("timestamp should be 11.0 but %#v", ts)
+	}
+}
+
+func TestHLCEncode(t *testing.T) {
+	ts1 := HLCTimestamp{WallTime: 1600000000000, Logical: 2}
+	ts2 := HLCTimestamp{WallTime: 1600000000000, Logical: 3}
+	ts3 := HLCTimestamp{WallTime: 1600000000001}
+
+	for _, ts := range []HLCTimestamp{ts1, ts2, ts3} {
+		if DecodeHLCTimestamp(ts.Encode()) != ts {
+			t.Errorf("timestamp should be decoded as %#v", ts)
+		}
+	}
+
+	if !(ts1.Encode() < ts2.Encode() && ts2.Encode() < ts3.Encode()) {
+		t.Error("encoded timestamps should be ordered")
+	}
+
+	if !ts1.Less(ts2) || !ts2.Less(ts3) || ts3.Less(ts1) {
+		t.Error("timestamps should be ordered")
+	}
+}
+
+func TestLWWRegAssignHLC(t *testing.T) {
+	// the clock of b is an hour behind the clock of a
+	pt_a := int64(3600000)
+	pt_b := int64(0)
+	hlc_a := newTestHLC(&pt_a)
+	hlc_b := newTestHLC(&pt_b)
+
+	lww1 := NewLWWReg()
+	lww1.AssignHLC("value1", "a", hlc_a)
+
+	lww2 := NewLWWReg()
+	lww2.Merge(lww1)
+	lww2.AssignHLC("value2", "b", hlc_b)
+
+	lww1.Merge(lww2)
+	if lww1.Value != "value2" {
+		t.Errorf("causally later write should win %#v", lww1)
+	}
+}
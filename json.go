@@ -0,0 +1,759 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// JSON encoding of the crdts. Every crdt is encoded as an object with
+// the name of its type, as returned by TypeName, and the version of the
+// schema, followed by its state:
+//
+//	{"type": "orswot", "version": 1, "clock": [...], "entries": [...]}
+//
+// Decoding fails unless the type and the version match. The state of
+// each type in version 1 is
+//
+//	dvv            "dots": [dot]
+//	dvvset         "entries": [{"node", "counter", "values": [string]}],
+//	               "anonymous": [string]
+//	gcounter       "counters": [{"actor", "count"}]
+//	pncounter      "p": [{"actor", "count"}], "n": [{"actor", "count"}]
+//	emcounter      "clock": [dot], "entries": [{"actor", "counter", "p", "n"}]
+//	gset           "elems": [elem]
+//	twopset        "adds": [elem], "removes": [elem]
+//	lwwreg         "value", "timestamp", "actor"
+//	mvregister     "entries": [{"dot", "context": [dot], "value"}]
+//	orset          "clock": [dot], "elems": [{"elem", "tags": [tag]}]
+//	rwset          "clock": [dot], "adds": [{"elem", "tags": [tag]}],
+//	               "removes": [{"elem", "tags": [tag]}]
+//	orswot         "clock": [dot], "entries": [{"elem", "dots": [dot]}]
+//	lwwelementset  "bias": "add-wins" or "remove-wins",
+//	               "adds": [{"elem", "timestamp", "actor"}],
+//	               "removes": [{"elem", "timestamp", "actor"}]
+//	ewflag, dwflag "clock": [dot], "dots": [dot]
+//	map            "clock": [dot], "entries": [{"name", "field_type",
+//	               "dots": [dot], "value": crdt}]
+//
+// where a dot is {"node", "counter", "timestamp"}, a tag is a dot with
+// "removed", and field types are "counter", "set", "register", "flag"
+// and "map". Elements, values and actors of the generic types are encoded
+// with encoding/json, and lists are sorted so equal crdts encode the same.
+// The clock a crdt reads the time from is not state and is not encoded,
+// and decoding into a crdt keeps its clock.
+
+package dt
+
+import (
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// The version of the schema written by MarshalJSON
+const JSONVersion = 1
+
+// Encode the dot as {"node", "counter", "timestamp"}
+func (d Dot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDot{Node: d.node, Counter: d.counter, Timestamp: d.timestamp})
+}
+
+func (d *Dot) UnmarshalJSON(data []byte) error {
+	var dot jsonDot
+	if err := json.Unmarshal(data, &dot); err != nil {
+		return err
+	}
+	*d = Dot{node: dot.Node, counter: dot.Counter, timestamp: dot.Timestamp}
+	return nil
+}
+
+func (v DVV) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDVV{jsonHeader: newJSONHeader("dvv"), Dots: encodeDots(v)})
+}
+
+func (v *DVV) UnmarshalJSON(data []byte) error {
+	var state jsonDVV
+	if err := decodeJSON(data, "dvv", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	return decodeDots(state.Dots, v)
+}
+
+func (c DVVSet) MarshalJSON() ([]byte, error) {
+	state := jsonDVVSet{jsonHeader: newJSONHeader("dvvset"), Entries: []jsonDVVEntry{}, Anonymous: encodeValues(c.anonymous)}
+	for _, e := range c.entries {
+		state.Entries = append(state.Entries, jsonDVVEntry{Node: e.node, Counter: e.counter, Values: encodeValues(e.values)})
+	}
+	return json.Marshal(state)
+}
+
+func (c *DVVSet) UnmarshalJSON(data []byte) error {
+	var state jsonDVVSet
+	if err := decodeJSON(data, "dvvset", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := DVVSet{anonymous: decodeValues(state.Anonymous)}
+	for idx, e := range state.Entries {
+		if idx > 0 && state.Entries[idx-1].Node >= e.Node {
+			return xerrors.Errorf("dvvset is not sorted by node at %q", e.Node)
+		}
+		if uint32(len(e.Values)) > e.Counter {
+			return xerrors.Errorf("dvvset has more values than events at %q", e.Node)
+		}
+		acc.entries = append(acc.entries, dvvEntry{node: e.Node, counter: e.Counter, values: decodeValues(e.Values)})
+	}
+	*c = acc
+	return nil
+}
+
+func (counter GCounterOf[A]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonGCounter[A]{jsonHeader: newJSONHeader("gcounter"), Counters: encodeCounts(counter)})
+}
+
+func (counter *GCounterOf[A]) UnmarshalJSON(data []byte) error {
+	var state jsonGCounter[A]
+	if err := decodeJSON(data, "gcounter", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc, err := decodeCounts(state.Counters)
+	if err != nil {
+		return err
+	}
+	*counter = acc
+	return nil
+}
+
+func (counter PNCounterOf[A]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonPNCounter[A]{jsonHeader: newJSONHeader("pncounter"), P: encodeCounts(counter.P), N: encodeCounts(counter.N)})
+}
+
+func (counter *PNCounterOf[A]) UnmarshalJSON(data []byte) error {
+	var state jsonPNCounter[A]
+	if err := decodeJSON(data, "pncounter", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	p, err := decodeCounts(state.P)
+	if err != nil {
+		return err
+	}
+	n, err := decodeCounts(state.N)
+	if err != nil {
+		return err
+	}
+	*counter = PNCounterOf[A]{P: p, N: n}
+	return nil
+}
+
+func (counter EMCounter) MarshalJSON() ([]byte, error) {
+	state := jsonEMCounter{jsonHeader: newJSONHeader("emcounter"), Clock: encodeDots(counter.Clock), Entries: []jsonEMEntry{}}
+	for _, actor := range sortedKeys(counter.Entries) {
+		entry := counter.Entries[actor]
+		state.Entries = append(state.Entries, jsonEMEntry{Actor: actor, Counter: entry.Counter, P: entry.P, N: entry.N})
+	}
+	return json.Marshal(state)
+}
+
+func (counter *EMCounter) UnmarshalJSON(data []byte) error {
+	var state jsonEMCounter
+	if err := decodeJSON(data, "emcounter", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := EMCounter{Clock: counter.Clock, Entries: map[string]EMEntry{}}
+	if err := decodeDots(state.Clock, &acc.Clock); err != nil {
+		return err
+	}
+	for _, entry := range state.Entries {
+		if _, ok := acc.Entries[entry.Actor]; ok {
+			return xerrors.Errorf("emcounter has duplicated actor %q", entry.Actor)
+		}
+		acc.Entries[entry.Actor] = EMEntry{Counter: entry.Counter, P: entry.P, N: entry.N}
+	}
+	*counter = acc
+	return nil
+}
+
+func (gset GSetOf[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonGSet[T]{jsonHeader: newJSONHeader("gset"), Elems: gset.Values()})
+}
+
+func (gset *GSetOf[T]) UnmarshalJSON(data []byte) error {
+	var state jsonGSet[T]
+	if err := decodeJSON(data, "gset", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	*gset = decodeElems(state.Elems)
+	return nil
+}
+
+func (twopset TwoPSetOf[T]) MarshalJSON() ([]byte, error) {
+	state := jsonTwoPSet[T]{jsonHeader: newJSONHeader("twopset"), Adds: twopset.Adds.Values(), Removes: twopset.Removes.Values()}
+	return json.Marshal(state)
+}
+
+func (twopset *TwoPSetOf[T]) UnmarshalJSON(data []byte) error {
+	var state jsonTwoPSet[T]
+	if err := decodeJSON(data, "twopset", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	*twopset = TwoPSetOf[T]{Adds: decodeElems(state.Adds), Removes: decodeElems(state.Removes)}
+	return nil
+}
+
+func (reg LWWRegOf[V]) MarshalJSON() ([]byte, error) {
+	state := jsonLWWReg[V]{jsonHeader: newJSONHeader("lwwreg"), Value: reg.Value, Timestamp: reg.Timestamp, Actor: reg.Actor}
+	return json.Marshal(state)
+}
+
+func (reg *LWWRegOf[V]) UnmarshalJSON(data []byte) error {
+	var state jsonLWWReg[V]
+	if err := decodeJSON(data, "lwwreg", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	*reg = LWWRegOf[V]{Value: state.Value, Timestamp: state.Timestamp, Actor: state.Actor, clock: reg.clock}
+	return nil
+}
+
+func (reg MVRegister) MarshalJSON() ([]byte, error) {
+	state := jsonMVRegister{jsonHeader: newJSONHeader("mvregister"), Entries: []jsonMVEntry{}}
+	for _, entry := range reg.sortedEntries() {
+		state.Entries = append(state.Entries, jsonMVEntry{Dot: entry.Dot, Context: encodeDots(entry.Context), Value: entry.Value})
+	}
+	return json.Marshal(state)
+}
+
+func (reg *MVRegister) UnmarshalJSON(data []byte) error {
+	var state jsonMVRegister
+	if err := decodeJSON(data, "mvregister", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := MVRegister{clock: reg.clock}
+	for _, entry := range state.Entries {
+		context := NewDVV()
+		if err := decodeDots(entry.Context, &context); err != nil {
+			return err
+		}
+		acc.Entries = append(acc.Entries, MVEntry{Dot: entry.Dot, Context: context, Value: entry.Value})
+	}
+	*reg = acc
+	return nil
+}
+
+func (orset ORSetOf[T]) MarshalJSON() ([]byte, error) {
+	state := jsonORSet[T]{jsonHeader: newJSONHeader("orset"), Clock: encodeDots(orset.Clock), Elems: encodeTokenSet(orset.Set)}
+	return json.Marshal(state)
+}
+
+func (orset *ORSetOf[T]) UnmarshalJSON(data []byte) error {
+	var state jsonORSet[T]
+	if err := decodeJSON(data, "orset", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := ORSetOf[T]{Clock: orset.Clock}
+	if err := decodeDots(state.Clock, &acc.Clock); err != nil {
+		return err
+	}
+	set, err := decodeTokenSet(state.Elems)
+	if err != nil {
+		return err
+	}
+	acc.Set = set
+	*orset = acc
+	return nil
+}
+
+func (rwset RWSet) MarshalJSON() ([]byte, error) {
+	state := jsonRWSet{
+		jsonHeader: newJSONHeader("rwset"),
+		Clock:      encodeDots(rwset.Clock),
+		Adds:       encodeTokenSet(rwset.Adds),
+		Removes:    encodeTokenSet(rwset.Removes),
+	}
+	return json.Marshal(state)
+}
+
+func (rwset *RWSet) UnmarshalJSON(data []byte) error {
+	var state jsonRWSet
+	if err := decodeJSON(data, "rwset", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := RWSet{Clock: rwset.Clock}
+	if err := decodeDots(state.Clock, &acc.Clock); err != nil {
+		return err
+	}
+	adds, err := decodeTokenSet(state.Adds)
+	if err != nil {
+		return err
+	}
+	removes, err := decodeTokenSet(state.Removes)
+	if err != nil {
+		return err
+	}
+	acc.Adds = adds
+	acc.Removes = removes
+	*rwset = acc
+	return nil
+}
+
+func (orswot ORSWOT) MarshalJSON() ([]byte, error) {
+	state := jsonORSWOT{jsonHeader: newJSONHeader("orswot"), Clock: encodeDots(orswot.Clock), Entries: []jsonORSWOTEntry{}}
+	for _, elem := range orswot.Value() {
+		state.Entries = append(state.Entries, jsonORSWOTEntry{Elem: elem, Dots: encodeDots(orswot.Entries[elem])})
+	}
+	return json.Marshal(state)
+}
+
+func (orswot *ORSWOT) UnmarshalJSON(data []byte) error {
+	var state jsonORSWOT
+	if err := decodeJSON(data, "orswot", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := ORSWOT{Clock: orswot.Clock, Entries: map[string]DVV{}}
+	if err := decodeDots(state.Clock, &acc.Clock); err != nil {
+		return err
+	}
+	for _, entry := range state.Entries {
+		if _, ok := acc.Entries[entry.Elem]; ok {
+			return xerrors.Errorf("orswot has duplicated element %q", entry.Elem)
+		}
+		dots := NewDVV()
+		if err := decodeDots(entry.Dots, &dots); err != nil {
+			return err
+		}
+		acc.Entries[entry.Elem] = dots
+	}
+	*orswot = acc
+	return nil
+}
+
+func (lwwset LWWElementSet) MarshalJSON() ([]byte, error) {
+	bias, ok := biasNames[lwwset.Bias]
+	if !ok {
+		return nil, xerrors.Errorf("lwwelementset has unknown bias %d", lwwset.Bias)
+	}
+	state := jsonLWWElementSet{
+		jsonHeader: newJSONHeader("lwwelementset"),
+		Bias:       bias,
+		Adds:       encodeStamps(lwwset.Adds),
+		Removes:    encodeStamps(lwwset.Removes),
+	}
+	return json.Marshal(state)
+}
+
+func (lwwset *LWWElementSet) UnmarshalJSON(data []byte) error {
+	var state jsonLWWElementSet
+	if err := decodeJSON(data, "lwwelementset", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := LWWElementSet{clock: lwwset.clock}
+	bias, ok := lookupName(biasNames, state.Bias)
+	if !ok {
+		return xerrors.Errorf("lwwelementset has unknown bias %q", state.Bias)
+	}
+	acc.Bias = bias
+	adds, err := decodeStamps(state.Adds)
+	if err != nil {
+		return err
+	}
+	removes, err := decodeStamps(state.Removes)
+	if err != nil {
+		return err
+	}
+	acc.Adds = adds
+	acc.Removes = removes
+	*lwwset = acc
+	return nil
+}
+
+func (flag EWFlag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonFlag{jsonHeader: newJSONHeader("ewflag"), Clock: encodeDots(flag.Clock), Dots: encodeDots(flag.Dots)})
+}
+
+func (flag *EWFlag) UnmarshalJSON(data []byte) error {
+	var state jsonFlag
+	if err := decodeJSON(data, "ewflag", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	return decodeFlag(state, &flag.Clock, &flag.Dots)
+}
+
+func (flag DWFlag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonFlag{jsonHeader: newJSONHeader("dwflag"), Clock: encodeDots(flag.Clock), Dots: encodeDots(flag.Dots)})
+}
+
+func (flag *DWFlag) UnmarshalJSON(data []byte) error {
+	var state jsonFlag
+	if err := decodeJSON(data, "dwflag", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	return decodeFlag(state, &flag.Clock, &flag.Dots)
+}
+
+func (m Map) MarshalJSON() ([]byte, error) {
+	state := jsonMap{jsonHeader: newJSONHeader("map"), Clock: encodeDots(m.Clock), Entries: []jsonMapEntry{}}
+	for _, field := range sortedFields(m.Entries) {
+		entry := m.Entries[field]
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		state.Entries = append(state.Entries, jsonMapEntry{
+			Name:      field.Name,
+			FieldType: fieldTypeNames[field.Type],
+			Dots:      encodeDots(entry.Dots),
+			Value:     value,
+		})
+	}
+	return json.Marshal(state)
+}
+
+func (m *Map) UnmarshalJSON(data []byte) error {
+	var state jsonMap
+	if err := decodeJSON(data, "map", &state.jsonHeader, &state); err != nil {
+		return err
+	}
+	acc := Map{Clock: m.Clock, Entries: map[Field]MapEntry{}}
+	if err := decodeDots(state.Clock, &acc.Clock); err != nil {
+		return err
+	}
+	for _, entry := range state.Entries {
+		field_type, ok := lookupName(fieldTypeNames, entry.FieldType)
+		if !ok {
+			return xerrors.Errorf("map has unknown field type %q", entry.FieldType)
+		}
+		field := Field{Name: entry.Name, Type: field_type}
+		if _, ok := acc.Entries[field]; ok {
+			return xerrors.Errorf("map has duplicated field %q", entry.Name)
+		}
+		dots := NewDVV()
+		if err := decodeDots(entry.Dots, &dots); err != nil {
+			return err
+		}
+		value := newEmbedded(field_type)
+		if err := json.Unmarshal(entry.Value, value); err != nil {
+			return err
+		}
+		acc.Entries[field] = MapEntry{Dots: dots, Value: value}
+	}
+	*m = acc
+	return nil
+}
+
+// ------------------- private functions -------------------
+
+type jsonHeader struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+type jsonDot struct {
+	Node      string `json:"node"`
+	Counter   uint32 `json:"counter"`
+	Timestamp uint32 `json:"timestamp"`
+}
+
+type jsonTag struct {
+	jsonDot
+	Removed bool `json:"removed"`
+}
+
+type jsonElemTags[T comparable] struct {
+	Elem T         `json:"elem"`
+	Tags []jsonTag `json:"tags"`
+}
+
+type jsonDVV struct {
+	jsonHeader
+	Dots []Dot `json:"dots"`
+}
+
+type jsonDVVEntry struct {
+	Node    string   `json:"node"`
+	Counter uint32   `json:"counter"`
+	Values  []string `json:"values"`
+}
+
+type jsonDVVSet struct {
+	jsonHeader
+	Entries   []jsonDVVEntry `json:"entries"`
+	Anonymous []string       `json:"anonymous"`
+}
+
+type jsonCount[A comparable] struct {
+	Actor A    `json:"actor"`
+	Count uint `json:"count"`
+}
+
+type jsonGCounter[A comparable] struct {
+	jsonHeader
+	Counters []jsonCount[A] `json:"counters"`
+}
+
+type jsonPNCounter[A comparable] struct {
+	jsonHeader
+	P []jsonCount[A] `json:"p"`
+	N []jsonCount[A] `json:"n"`
+}
+
+type jsonEMEntry struct {
+	Actor   string `json:"actor"`
+	Counter uint32 `json:"counter"`
+	P       uint   `json:"p"`
+	N       uint   `json:"n"`
+}
+
+type jsonEMCounter struct {
+	jsonHeader
+	Clock   []Dot         `json:"clock"`
+	Entries []jsonEMEntry `json:"entries"`
+}
+
+type jsonGSet[T comparable] struct {
+	jsonHeader
+	Elems []T `json:"elems"`
+}
+
+type jsonTwoPSet[T comparable] struct {
+	jsonHeader
+	Adds    []T `json:"adds"`
+	Removes []T `json:"removes"`
+}
+
+type jsonLWWReg[V any] struct {
+	jsonHeader
+	Value     V      `json:"value"`
+	Timestamp int64  `json:"timestamp"`
+	Actor     string `json:"actor"`
+}
+
+type jsonMVEntry struct {
+	Dot     Dot    `json:"dot"`
+	Context []Dot  `json:"context"`
+	Value   string `json:"value"`
+}
+
+type jsonMVRegister struct {
+	jsonHeader
+	Entries []jsonMVEntry `json:"entries"`
+}
+
+type jsonORSet[T comparable] struct {
+	jsonHeader
+	Clock []Dot             `json:"clock"`
+	Elems []jsonElemTags[T] `json:"elems"`
+}
+
+type jsonRWSet struct {
+	jsonHeader
+	Clock   []Dot                  `json:"clock"`
+	Adds    []jsonElemTags[string] `json:"adds"`
+	Removes []jsonElemTags[string] `json:"removes"`
+}
+
+type jsonORSWOTEntry struct {
+	Elem string `json:"elem"`
+	Dots []Dot  `json:"dots"`
+}
+
+type jsonORSWOT struct {
+	jsonHeader
+	Clock   []Dot             `json:"clock"`
+	Entries []jsonORSWOTEntry `json:"entries"`
+}
+
+type jsonStamp struct {
+	Elem      string `json:"elem"`
+	Timestamp int64  `json:"timestamp"`
+	Actor     string `json:"actor"`
+}
+
+type jsonLWWElementSet struct {
+	jsonHeader
+	Bias    string      `json:"bias"`
+	Adds    []jsonStamp `json:"adds"`
+	Removes []jsonStamp `json:"removes"`
+}
+
+type jsonFlag struct {
+	jsonHeader
+	Clock []Dot `json:"clock"`
+	Dots  []Dot `json:"dots"`
+}
+
+type jsonMapEntry struct {
+	Name      string          `json:"name"`
+	FieldType string          `json:"field_type"`
+	Dots      []Dot           `json:"dots"`
+	Value     json.RawMessage `json:"value"`
+}
+
+type jsonMap struct {
+	jsonHeader
+	Clock   []Dot          `json:"clock"`
+	Entries []jsonMapEntry `json:"entries"`
+}
+
+var biasNames = map[Bias]string{
+	AddWins:    "add-wins",
+	RemoveWins: "remove-wins",
+}
+
+var fieldTypeNames = map[FieldType]string{
+	CounterField:  "counter",
+	SetField:      "set",
+	RegisterField: "register",
+	FlagField:     "flag",
+	MapField:      "map",
+}
+
+func newJSONHeader(name string) jsonHeader {
+	return jsonHeader{Type: name, Version: JSONVersion}
+}
+
+// Decode data into state, whose header must be of the type name
+// and of the current version
+func decodeJSON(data []byte, name string, header *jsonHeader, state interface{}) error {
+	if err := json.Unmarshal(data, state); err != nil {
+		return err
+	}
+	if header.Type != name {
+		return xerrors.Errorf("expected %q but got %q", name, header.Type)
+	}
+	if header.Version != JSONVersion {
+		return xerrors.Errorf("unsupported version %d of %q", header.Version, name)
+	}
+	return nil
+}
+
+func encodeDots(v DVV) []Dot {
+	return append([]Dot{}, v.vector...)
+}
+
+// Decode the dots into the vclock, keeping its clock
+func decodeDots(dots []Dot, v *DVV) error {
+	acc := DVV{clock: v.clock}
+	if len(dots) > 0 {
+		acc.vector = dots
+	}
+	if err := acc.Validate(); err != nil {
+		return err
+	}
+	*v = acc
+	return nil
+}
+
+func encodeValues(values []string) []string {
+	return append([]string{}, values...)
+}
+
+func decodeValues(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+func encodeCounts[A comparable](counter GCounterOf[A]) []jsonCount[A] {
+	acc := []jsonCount[A]{}
+	for _, actor := range sortedKeys(counter.Counters) {
+		acc = append(acc, jsonCount[A]{Actor: actor, Count: counter.Counters[actor]})
+	}
+	return acc
+}
+
+func decodeCounts[A comparable](counts []jsonCount[A]) (GCounterOf[A], error) {
+	acc := NewGCounterOf[A]()
+	for _, count := range counts {
+		if _, ok := acc.Counters[count.Actor]; ok {
+			return acc, xerrors.Errorf("gcounter has duplicated actor %v", count.Actor)
+		}
+		acc.Counters[count.Actor] = count.Count
+	}
+	return acc, nil
+}
+
+func decodeElems[T comparable](elems []T) GSetOf[T] {
+	acc := NewGSetOf[T]()
+	for _, elem := range elems {
+		acc.Add(elem)
+	}
+	return acc
+}
+
+func encodeTokenSet[T comparable](set map[T]Tokens) []jsonElemTags[T] {
+	acc := []jsonElemTags[T]{}
+	for _, elem := range sortedKeys(set) {
+		tags := []jsonTag{}
+		for _, tag := range set[elem].sorted() {
+			dot := jsonDot{Node: tag.node, Counter: tag.counter, Timestamp: tag.timestamp}
+			tags = append(tags, jsonTag{jsonDot: dot, Removed: set[elem][tag]})
+		}
+		acc = append(acc, jsonElemTags[T]{Elem: elem, Tags: tags})
+	}
+	return acc
+}
+
+func decodeTokenSet[T comparable](elems []jsonElemTags[T]) (map[T]Tokens, error) {
+	acc := map[T]Tokens{}
+	for _, elem := range elems {
+		if _, ok := acc[elem.Elem]; ok {
+			return nil, xerrors.Errorf("set has duplicated element %v", elem.Elem)
+		}
+		tokens := Tokens{}
+		for _, tag := range elem.Tags {
+			tokens[Dot{node: tag.Node, counter: tag.Counter, timestamp: tag.Timestamp}] = tag.Removed
+		}
+		acc[elem.Elem] = tokens
+	}
+	return acc, nil
+}
+
+func encodeStamps(stamps map[string]LWWStamp) []jsonStamp {
+	acc := []jsonStamp{}
+	for _, elem := range sortedKeys(stamps) {
+		stamp := stamps[elem]
+		acc = append(acc, jsonStamp{Elem: elem, Timestamp: stamp.Timestamp, Actor: stamp.Actor})
+	}
+	return acc
+}
+
+func decodeStamps(stamps []jsonStamp) (map[string]LWWStamp, error) {
+	acc := map[string]LWWStamp{}
+	for _, stamp := range stamps {
+		if _, ok := acc[stamp.Elem]; ok {
+			return nil, xerrors.Errorf("lwwelementset has duplicated element %q", stamp.Elem)
+		}
+		acc[stamp.Elem] = LWWStamp{Timestamp: stamp.Timestamp, Actor: stamp.Actor}
+	}
+	return acc, nil
+}
+
+func decodeFlag(state jsonFlag, clock *DVV, dots *DVV) error {
+	acc_clock := *clock
+	if err := decodeDots(state.Clock, &acc_clock); err != nil {
+		return err
+	}
+	acc_dots := NewDVV()
+	if err := decodeDots(state.Dots, &acc_dots); err != nil {
+		return err
+	}
+	*clock = acc_clock
+	*dots = acc_dots
+	return nil
+}
+
+// The keys of the map, sorted
+func sortedKeys[K comparable, V any](m map[K]V) []K {
+	acc := make([]K, 0, len(m))
+	for key := range m {
+		acc = append(acc, key)
+	}
+	sortValues(acc)
+	return acc
+}
+
+// The key of the name
+func lookupName[K comparable](names map[K]string, name string) (K, bool) {
+	for key, other := range names {
+		if other == name {
+			return key, true
+		}
+	}
+	var zero K
+	return zero, false
+}
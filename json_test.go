@@ -0,0 +1,152 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, replica := range replicas {
+		a := replica("a")
+		a.MergeCRDT(replica("b"))
+		name := a.TypeName()
+
+		data, err := json.Marshal(a)
+		if err != nil {
+			t.Errorf("%s: marshal should succeed %v", name, err)
+			continue
+		}
+		decoded := reflect.New(reflect.TypeOf(a).Elem()).Interface().(CRDT)
+		if err := json.Unmarshal(data, decoded); err != nil {
+			t.Errorf("%s: unmarshal should succeed %v %s", name, err, data)
+			continue
+		}
+		if !decoded.EqualCRDT(a) {
+			t.Errorf("%s: decoded should be equal with the crdt %#v %#v", name, decoded, a)
+		}
+		again, _ := json.Marshal(decoded)
+		if string(again) != string(data) {
+			t.Errorf("%s: encoding should round trip exactly %s %s", name, data, again)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	orswot := NewORSWOTWithClock(clock)
+	orswot.Add("x", "a")
+	orswot.Add("y", "b")
+	orswot.Remove("y")
+
+	data, _ := json.Marshal(orswot)
+	expected := `{"type":"orswot","version":1,` +
+		`"clock":[{"node":"a","counter":1,"timestamp":1600000000},{"node":"b","counter":1,"timestamp":1600000000}],` +
+		`"entries":[{"elem":"x","dots":[{"node":"a","counter":1,"timestamp":1600000000}]}]}`
+	if string(data) != expected {
+		t.Errorf("orswot should be encoded as %s but %s", expected, data)
+	}
+}
+
+func TestJSONTombstones(t *testing.T) {
+	orset := NewOrset()
+	orset.Add("x", "a")
+	orset.Add("y", "a")
+	orset.Remove("y")
+
+	data, _ := json.Marshal(orset)
+	decoded := NewOrset()
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Errorf("unmarshal should succeed %v", err)
+	}
+	if !reflect.DeepEqual(decoded.RemovedValue(), []string{"y"}) {
+		t.Errorf("removed elements should be kept %#v", decoded)
+	}
+
+	twopset := NewTwoPSet()
+	twopset.Add("x")
+	twopset.Remove("x")
+	data, _ = json.Marshal(twopset)
+	decoded_twopset := NewTwoPSet()
+	json.Unmarshal(data, &decoded_twopset)
+	decoded_twopset.Add("x")
+	if decoded_twopset.Exists("x") {
+		t.Errorf("removed elements shouldn't be added again %#v", decoded_twopset)
+	}
+}
+
+func TestJSONGeneric(t *testing.T) {
+	reg := NewLWWRegOf[[]byte]()
+	reg.AssignByTS([]byte("value"), "a", 10)
+	data, _ := json.Marshal(reg)
+
+	decoded := NewLWWRegOf[[]byte]()
+	if err := json.Unmarshal(data, &decoded); err != nil || !decoded.Equal(reg) {
+		t.Errorf("decoded should be equal with the register %#v %v", decoded, err)
+	}
+
+	counter := NewGCounterOf[int]()
+	counter.IncrementBy(7, 3)
+	data, _ = json.Marshal(counter)
+	expected := `{"type":"gcounter","version":1,"counters":[{"actor":7,"count":3}]}`
+	if string(data) != expected {
+		t.Errorf("gcounter should be encoded as %s but %s", expected, data)
+	}
+}
+
+func TestJSONKeepsClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	orswot := NewORSWOT()
+	orswot.Add("x", "a")
+	data, _ := json.Marshal(orswot)
+
+	decoded := NewORSWOTWithClock(clock)
+	json.Unmarshal(data, &decoded)
+	decoded.Add("y", "a")
+	if ts, _ := decoded.Clock.GetTimestamp("a"); ts != 1600000000 {
+		t.Errorf("decoded should read time from the clock %d", ts)
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	cases := []struct {
+		data  string
+		value interface{}
+	}{
+		{`{"type":"gset","version":1,"elems":[]}`, &ORSWOT{}},
+		{`{"type":"orswot","version":2,"clock":[],"entries":[]}`, &ORSWOT{}},
+		{`{"type":"dvv","version":1,"dots":[{"node":"b","counter":1},{"node":"a","counter":1}]}`, &DVV{}},
+		{`{"type":"orswot","version":1,"clock":[],"entries":[{"elem":"x","dots":[]},{"elem":"x","dots":[]}]}`, &ORSWOT{}},
+		{`{"type":"lwwelementset","version":1,"bias":"none","adds":[],"removes":[]}`, &LWWElementSet{}},
+		{`{"type":"map","version":1,"clock":[],"entries":[{"name":"x","field_type":"set","dots":[],` +
+			`"value":{"type":"gset","version":1,"elems":[]}}]}`, &Map{}},
+	}
+	for i, c := range cases {
+		if err := json.Unmarshal([]byte(c.data), c.value); err == nil {
+			t.Errorf("case %d: unmarshal should fail %#v", i, c.value)
+		}
+	}
+}
+
+func TestJSONMVRegisterOrder(t *testing.T) {
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	reg_a := NewMVRegisterWithClock(clock)
+	reg_a.Assign("value1", NewDVV(), "a")
+	reg_b := NewMVRegisterWithClock(clock)
+	reg_b.Assign("value2", NewDVV(), "b")
+
+	merged1 := reg_a.Clone()
+	merged1.Merge(reg_b)
+	merged2 := reg_b.Clone()
+	merged2.Merge(reg_a)
+
+	data1, _ := json.Marshal(merged1)
+	data2, _ := json.Marshal(merged2)
+	if !merged1.Equal(merged2) || string(data1) != string(data2) {
+		t.Errorf("equal registers should encode the same %s %s", data1, data2)
+	}
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// A state-based last-writer-wins element set. Every element keeps the
+// latest add and the latest remove, ordered like the writes of an lwwreg.
+
+package dt
+
+import (
+	"errors"
+	"sort"
+)
+
+// Which of an add and a remove with the same timestamp wins
+type Bias int
+
+const (
+	AddWins Bias = iota
+	RemoveWins
+)
+
+// Returned when merging lwwelementsets with different biases
+var ErrBiasMismatch = errors.New("precondition: the lwwelementsets have different biases")
+
+// Time of a write with its writer
+type LWWStamp struct {
+	Timestamp int64
+	Actor     string
+}
+
+type LWWElementSet struct {
+	Bias    Bias
+	Adds    map[string]LWWStamp
+	Removes map[string]LWWStamp
+	clock   Clock
+}
+
+// Create a new lwwelementset
+func NewLWWElementSet(bias Bias) LWWElementSet {
+	return LWWElementSet{Bias: bias, Adds: map[string]LWWStamp{}, Removes: map[string]LWWStamp{}}
+}
+
+// Create a new lwwelementset which reads timestamps from the clock
+func NewLWWElementSetWithClock(bias Bias, clock Clock) LWWElementSet {
+	lwwset := NewLWWElementSet(bias)
+	lwwset.clock = clock
+	return lwwset
+}
+
+// Returns the elements in the set, sorted.
+func (lwwset LWWElementSet) Value() []string {
+	acc := []string{}
+	for elem := range lwwset.Adds {
+		if lwwset.Lookup(elem) {
+			acc = append(acc, elem)
+		}
+	}
+	sort.Strings(acc)
+	return acc
+}
+
+// return true if the latest add of the element wins over its latest remove
+func (lwwset LWWElementSet) Lookup(elem string) bool {
+	add, ok := lwwset.Adds[elem]
+	if !ok {
+		return false
+	}
+	remove, ok := lwwset.Removes[elem]
+	if !ok || add.Timestamp > remove.Timestamp {
+		return true
+	}
+	return add.Timestamp == remove.Timestamp && lwwset.Bias == AddWins
+}
+
+// Add an element written by the actor
+func (lwwset *LWWElementSet) Add(elem string, actor string) {
+	lwwset.AddTS(elem, actor, lwwset.now())
+}
+
+// Add an element written by the actor at time ts
+func (lwwset *LWWElementSet) AddTS(elem string, actor string, ts int64) {
+	assignStamp(lwwset.Adds, elem, LWWStamp{Timestamp: ts, Actor: actor})
+}
+
+// Add an element written by the actor with a timestamp from the hybrid
+// logical clock, which observes the latest write of the element first.
+// A set should be written either with hlc timestamps or with wall clock
+// ones, not both.
+func (lwwset *LWWElementSet) AddHLC(elem string, actor string, clock *HLC) {
+	lwwset.AddTS(elem, actor, lwwset.nextHLC(elem, clock))
+}
+
+// Remove an element written by the actor
+func (lwwset *LWWElementSet) Remove(elem string, actor string) {
+	lwwset.RemoveTS(elem, actor, lwwset.now())
+}
+
+// Remove an element written by the actor at time ts. The element need
+// not be in the set, an earlier or concurrent add is removed anyway.
+func (lwwset *LWWElementSet) RemoveTS(elem string, actor string, ts int64) {
+	assignStamp(lwwset.Removes, elem, LWWStamp{Timestamp: ts, Actor: actor})
+}
+
+// Remove an element written by the actor with a timestamp from the
+// hybrid logical clock, like AddHLC
+func (lwwset *LWWElementSet) RemoveHLC(elem string, actor string, clock *HLC) {
+	lwwset.RemoveTS(elem, actor, lwwset.nextHLC(elem, clock))
+}
+
+// Merge two lwwelementset to a single lwwelementset, keeping the latest
+// add and remove of every element. Sets with different biases resolve
+// ties differently, so they can't be merged.
+func (lwwset_a *LWWElementSet) Merge(lwwset_b LWWElementSet) error {
+	if lwwset_a.Bias != lwwset_b.Bias {
+		return ErrBiasMismatch
+	}
+	for elem, stamp := range lwwset_b.Adds {
+		assignStamp(lwwset_a.Adds, elem, stamp)
+	}
+	for elem, stamp := range lwwset_b.Removes {
+		assignStamp(lwwset_a.Removes, elem, stamp)
+	}
+	return nil
+}
+
+// Compare two lwwelementset for equality
+func (lwwset_a LWWElementSet) Equal(lwwset_b LWWElementSet) bool {
+	return lwwset_a.Bias == lwwset_b.Bias &&
+		equalStamps(lwwset_a.Adds, lwwset_b.Adds) &&
+		equalStamps(lwwset_a.Removes, lwwset_b.Removes)
+}
+
+// Return a copy of the set
+func (lwwset LWWElementSet) Clone() LWWElementSet {
+	acc := NewLWWElementSetWithClock(lwwset.Bias, lwwset.clock)
+	for elem, stamp := range lwwset.Adds {
+		acc.Adds[elem] = stamp
+	}
+	for elem, stamp := range lwwset.Removes {
+		acc.Removes[elem] = stamp
+	}
+	return acc
+}
+
+// ------------------- private functions -------------------
+
+// The current time of the clock in nanoseconds
+func (lwwset LWWElementSet) now() int64 {
+	return clockOrSystem(lwwset.clock).Now().UnixNano()
+}
+
+// The next hlc timestamp of a write of the element
+func (lwwset LWWElementSet) nextHLC(elem string, clock *HLC) int64 {
+	latest := lwwset.Adds[elem].Timestamp
+	if remove := lwwset.Removes[elem].Timestamp; remove > latest {
+		latest = remove
+	}
+	return clock.Update(DecodeHLCTimestamp(latest)).Encode()
+}
+
+// keep the stamp of the element if it wins over the one in stamps
+func assignStamp(stamps map[string]LWWStamp, elem string, stamp LWWStamp) {
+	current, ok := stamps[elem]
+	if !ok || lwwLess(current.Timestamp, current.Actor, elem, stamp.Timestamp, stamp.Actor, elem) {
+		stamps[elem] = stamp
+	}
+}
+
+func equalStamps(stamps_a map[string]LWWStamp, stamps_b map[string]LWWStamp) bool {
+	if len(stamps_a) != len(stamps_b) {
+		return false
+	}
+	for elem, stamp := range stamps_a {
+		if other, ok := stamps_b[elem]; !ok || other != stamp {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewLWWElementSet(t *testing.T) {
+	lwwset := NewLWWElementSet(AddWins)
+	if len(lwwset.Value()) != 0 {
+		t.Errorf("lwwelementset.value should be empty %#v", lwwset)
+	}
+}
+
+func TestLWWElementSetAddRemove(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 1000))
+	lwwset := NewLWWElementSetWithClock(AddWins, clock)
+	lwwset.Add("value1", "a")
+	lwwset.Add("value2", "a")
+
+	clock.Advance(time.Nanosecond)
+	lwwset.Remove("value1", "a")
+	if !reflect.DeepEqual(lwwset.Value(), []string{"value2"}) {
+		t.Errorf("lwwelementset.value should be value2 %#v", lwwset.Value())
+	}
+
+	clock.Advance(time.Nanosecond)
+	lwwset.Add("value1", "a")
+	if !reflect.DeepEqual(lwwset.Value(), []string{"value1", "value2"}) {
+		t.Errorf("value1 should be added again %#v", lwwset.Value())
+	}
+
+	// a late remove with an older timestamp has no effect
+	lwwset.RemoveTS("value2", "b", 999)
+	if !lwwset.Lookup("value2") {
+		t.Errorf("value2 should be in the set %#v", lwwset)
+	}
+}
+
+func TestLWWElementSetBias(t *testing.T) {
+	addwins := NewLWWElementSet(AddWins)
+	addwins.AddTS("value1", "a", 10)
+	addwins.RemoveTS("value1", "b", 10)
+	if !addwins.Lookup("value1") {
+		t.Errorf("add should win over a remove with the same timestamp %#v", addwins)
+	}
+
+	removewins := NewLWWElementSet(RemoveWins)
+	removewins.AddTS("value1", "a", 10)
+	removewins.RemoveTS("value1", "b", 10)
+	if removewins.Lookup("value1") {
+		t.Errorf("remove should win over an add with the same timestamp %#v", removewins)
+	}
+}
+
+func TestLWWElementSetMerge(t *testing.T) {
+	lwwset1 := NewLWWElementSet(RemoveWins)
+	lwwset1.AddTS("value1", "a", 10)
+	lwwset1.AddTS("value2", "a", 10)
+
+	lwwset2 := NewLWWElementSet(RemoveWins)
+	lwwset2.AddTS("value2", "b", 10)
+	lwwset2.RemoveTS("value1", "b", 11)
+	lwwset2.AddTS("value3", "b", 12)
+
+	merged1 := NewLWWElementSet(RemoveWins)
+	merged1.Merge(lwwset1)
+	merged1.Merge(lwwset2)
+	merged2 := NewLWWElementSet(RemoveWins)
+	merged2.Merge(lwwset2)
+	merged2.Merge(lwwset1)
+
+	if !merged1.Equal(merged2) {
+		t.Errorf("merge should be commutative %#v %#v", merged1, merged2)
+	}
+
+	if !reflect.DeepEqual(merged1.Value(), []string{"value2", "value3"}) {
+		t.Errorf("merged.value should be value2 and value3 %#v", merged1.Value())
+	}
+
+	// concurrent adds with the same timestamp are ordered by actor
+	if merged1.Adds["value2"].Actor != "b" {
+		t.Errorf("add of b should win %#v", merged1.Adds["value2"])
+	}
+}
+
+func TestLWWElementSetBiasMismatch(t *testing.T) {
+	lwwset1 := NewLWWElementSet(AddWins)
+	lwwset1.AddTS("value1", "a", 10)
+	lwwset2 := NewLWWElementSet(RemoveWins)
+	lwwset2.RemoveTS("value1", "b", 10)
+
+	if err := lwwset1.Merge(lwwset2); err != ErrBiasMismatch {
+		t.Errorf("merge of different biases should fail %v", err)
+	}
+	if !lwwset1.Lookup("value1") || len(lwwset1.Removes) != 0 {
+		t.Errorf("failed merge should leave the set as it is %#v", lwwset1)
+	}
+}
+
+func TestLWWElementSetHLC(t *testing.T) {
+	// the clock of b is an hour behind the clock of a
+	pt_a := int64(3600000)
+	pt_b := int64(0)
+	hlc_a := newTestHLC(&pt_a)
+	hlc_b := newTestHLC(&pt_b)
+
+	lwwset1 := NewLWWElementSet(AddWins)
+	lwwset1.AddHLC("value1", "a", hlc_a)
+
+	lwwset2 := NewLWWElementSet(AddWins)
+	if err := lwwset2.Merge(lwwset1); err != nil {
+		t.Fatalf("merge should succeed %v", err)
+	}
+	lwwset2.RemoveHLC("value1", "b", hlc_b)
+
+	if err := lwwset1.Merge(lwwset2); err != nil {
+		t.Fatalf("merge should succeed %v", err)
+	}
+	if lwwset1.Lookup("value1") {
+		t.Errorf("causally later remove should win %#v", lwwset1)
+	}
+
+	lwwset1.AddHLC("value1", "b", hlc_b)
+	if !lwwset1.Lookup("value1") {
+		t.Errorf("causally later add should win %#v", lwwset1)
+	}
+}
@@ -3,37 +3,82 @@
 package dt
 
 import (
-	"time"
+	"reflect"
 )
 
-type LWWReg struct {
-	Value     string
+// Actor is the writer of the value, which breaks ties between
+// writes with the same timestamp. Values are replaced, and never
+// modified in place.
+type LWWRegOf[V any] struct {
+	Value     V
 	Timestamp int64
+	Actor     string
+	clock     Clock
 }
 
+// An lwwreg of strings
+type LWWReg = LWWRegOf[string]
+
 // Create a new empty lwwreg
 func NewLWWReg() LWWReg {
 	return LWWReg{}
 }
 
+// Create a new empty lwwreg which reads timestamps from the clock
+func NewLWWRegWithClock(clock Clock) LWWReg {
+	return LWWReg{clock: clock}
+}
+
+// Create a new empty lwwreg of values of type V
+func NewLWWRegOf[V any]() LWWRegOf[V] {
+	return LWWRegOf[V]{}
+}
+
+// Create a new empty lwwreg of values of type V which reads timestamps from the clock
+func NewLWWRegOfWithClock[V any](clock Clock) LWWRegOf[V] {
+	return LWWRegOf[V]{clock: clock}
+}
+
 // Assign a value to the lwwreg associating the update with time ts
-func (reg *LWWReg) Assign(value string) {
-	reg.AssignTS(value, time.Now().UnixNano())
+func (reg *LWWRegOf[V]) Assign(value V) {
+	reg.AssignTS(value, reg.now())
+}
+
+func (reg *LWWRegOf[V]) AssignTS(value V, ts int64) {
+	reg.AssignByTS(value, "", ts)
+}
+
+// Assign a value written by the actor to the lwwreg
+func (reg *LWWRegOf[V]) AssignBy(value V, actor string) {
+	reg.AssignByTS(value, actor, reg.now())
 }
 
-func (reg *LWWReg) AssignTS(value string, ts int64) {
-	if ts > reg.Timestamp {
+// Assign a value written by the actor at time ts to the lwwreg
+func (reg *LWWRegOf[V]) AssignByTS(value V, actor string, ts int64) {
+	if lwwLess(reg.Timestamp, reg.Actor, reg.Value, ts, actor, value) {
 		reg.Value = value
 		reg.Timestamp = ts
+		reg.Actor = actor
 	}
 }
 
+// Assign a value written by the actor with a timestamp from the hybrid
+// logical clock. The clock observes the timestamp of the current value
+// first, so the write wins over every write this replica has seen, even
+// when the wall clock of the writer is behind. A register should be
+// written either with hlc timestamps or with wall clock ones, not both.
+func (reg *LWWRegOf[V]) AssignHLC(value V, actor string, clock *HLC) {
+	ts := clock.Update(DecodeHLCTimestamp(reg.Timestamp))
+	reg.AssignByTS(value, actor, ts.Encode())
+}
+
 // Merge two lwwreg to a single lwwreg. this is the least upper bound
 // function described in the literature
-func (reg_a *LWWReg) Merge(reg_b LWWReg) {
-	if reg_b.Timestamp > reg_a.Timestamp {
+func (reg_a *LWWRegOf[V]) Merge(reg_b LWWRegOf[V]) {
+	if lwwLess(reg_a.Timestamp, reg_a.Actor, reg_a.Value, reg_b.Timestamp, reg_b.Actor, reg_b.Value) {
 		reg_a.Timestamp = reg_b.Timestamp
 		reg_a.Value = reg_b.Value
+		reg_a.Actor = reg_b.Actor
 	} else {
 		return
 	}
@@ -41,7 +86,32 @@ func (reg_a *LWWReg) Merge(reg_b LWWReg) {
 
 // Are two lwwreg s structurally equal? this is not value equality.
 // Two regsiters might represent the value armchair and not be equal().
-// Equality here is that both registers contain the same value and timestamp
-func (reg_a LWWReg) Equal(reg_b LWWReg) bool {
-	return reg_a.Value == reg_b.Value && reg_a.Timestamp == reg_b.Timestamp
+// Equality here is that both registers contain the same value, timestamp and actor
+func (reg_a LWWRegOf[V]) Equal(reg_b LWWRegOf[V]) bool {
+	return reflect.DeepEqual(reg_a.Value, reg_b.Value) && reg_a.Timestamp == reg_b.Timestamp && reg_a.Actor == reg_b.Actor
+}
+
+// Return a copy of the register
+func (reg LWWRegOf[V]) Clone() LWWRegOf[V] {
+	return reg
+}
+
+// ------------------- private functions -------------------
+
+// The current time of the clock in nanoseconds
+func (reg LWWRegOf[V]) now() int64 {
+	return clockOrSystem(reg.clock).Now().UnixNano()
+}
+
+// true if the write a loses to the write b. Writes are ordered by
+// timestamp, then by actor, then by value, so that every replica
+// picks the same winner whatever order it sees the writes in.
+func lwwLess[V any](ts_a int64, actor_a string, value_a V, ts_b int64, actor_b string, value_b V) bool {
+	if ts_a != ts_b {
+		return ts_a < ts_b
+	}
+	if actor_a != actor_b {
+		return actor_a < actor_b
+	}
+	return lessValue(value_a, value_b)
 }
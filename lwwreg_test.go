@@ -4,6 +4,7 @@
 package dt
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -85,3 +86,83 @@ func TestLWWRegEqual(t *testing.T) {
 		t.Error("LWW4 should be equal lww2")
 	}
 }
+
+func TestLWWRegAssignBy(t *testing.T) {
+	lww1 := NewLWWReg()
+	lww1.AssignByTS("value1", "b", 1)
+	lww1.AssignByTS("value2", "a", 1)
+	if lww1.Value != "value1" || lww1.Actor != "b" {
+		t.Errorf("LWW1.value should be value1 written by b %#v", lww1)
+	}
+
+	lww1.AssignByTS("value0", "b", 1)
+	if lww1.Value != "value1" {
+		t.Errorf("LWW1.value should be value1 %#v", lww1)
+	}
+
+	lww1.AssignBy("value3", "a")
+	if lww1.Value != "value3" || lww1.Actor != "a" {
+		t.Errorf("LWW1.value should be value3 written by a %#v", lww1)
+	}
+}
+
+func TestLWWRegMergeTie(t *testing.T) {
+	regs := []LWWReg{
+		LWWReg{Value: "value1", Timestamp: 1000, Actor: "a"},
+		LWWReg{Value: "value2", Timestamp: 1000, Actor: "b"},
+		LWWReg{Value: "value3", Timestamp: 1000, Actor: "b"},
+		LWWReg{Value: "value4", Timestamp: 999, Actor: "c"},
+	}
+	expected := LWWReg{Value: "value3", Timestamp: 1000, Actor: "b"}
+
+	for i := range regs {
+		for j := range regs {
+			lww1 := regs[i]
+			lww2 := regs[j]
+			lww1.Merge(lww2)
+			lww2.Merge(regs[i])
+			if !lww1.Equal(lww2) {
+				t.Errorf("merge should be commutative %#v %#v", lww1, lww2)
+			}
+		}
+	}
+
+	lww1 := NewLWWReg()
+	lww2 := NewLWWReg()
+	for i := range regs {
+		lww1.Merge(regs[i])
+		lww2.Merge(regs[len(regs)-1-i])
+	}
+	if !lww1.Equal(expected) || !lww2.Equal(expected) {
+		t.Errorf("replicas should converge to expected %#v %#v", lww1, lww2)
+	}
+}
+
+func TestLWWRegOf(t *testing.T) {
+	lww1 := NewLWWRegOf[[]byte]()
+	lww1.AssignByTS([]byte{1, 2}, "a", 10)
+	lww2 := NewLWWRegOf[[]byte]()
+	lww2.AssignByTS([]byte{1, 3}, "a", 10)
+
+	merged := lww1.Clone()
+	merged.Merge(lww2)
+	lww2.Merge(lww1)
+	if !merged.Equal(lww2) || !reflect.DeepEqual(merged.Value, []byte{1, 3}) {
+		t.Errorf("the greater value should win a tie %#v %#v", merged, lww2)
+	}
+}
+
+func TestLWWRegOfInterface(t *testing.T) {
+	// ties between values of different types are broken without panicking
+	lww := NewLWWRegOf[any]()
+	lww.AssignByTS("x", "a", 5)
+	lww.AssignByTS(1, "a", 5)
+
+	lww2 := NewLWWRegOf[any]()
+	lww2.AssignByTS(1, "a", 5)
+	lww2.AssignByTS("x", "a", 5)
+
+	if !reflect.DeepEqual(lww.Value, lww2.Value) {
+		t.Errorf("the same value should win on every replica %#v %#v", lww.Value, lww2.Value)
+	}
+}
@@ -0,0 +1,293 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// A map of embedded CRDTs
+// based on riak_dt_map
+
+package dt
+
+import (
+	"sort"
+)
+
+// Type of the crdt of a field
+type FieldType int
+
+const (
+	CounterField  FieldType = iota // *EMCounter
+	SetField                       // *ORSWOT
+	RegisterField                  // *LWWReg
+	FlagField                      // *EWFlag
+	MapField                       // *Map
+)
+
+// A field is identified by its name and type, so fields with the same
+// name and different types are different fields
+type Field struct {
+	Name string
+	Type FieldType
+}
+
+// The crdt of a field, with the dots of the updates that made it present
+type MapEntry struct {
+	Dots  DVV
+	Value CRDT
+}
+
+// Fields are present while they have dots which have not been removed,
+// like the elements of an ORSWOT. Embedded crdts draw their dots from the
+// clock of the map, and removing a field resets it: when an update races
+// with the remove, only the effects the remove has not seen survive.
+// Registers have no dots, and survive a racing update whole.
+type Map struct {
+	Clock   DVV
+	Entries map[Field]MapEntry
+}
+
+// An operation on a field, applied by Map.Update
+type MapOp struct {
+	field  Field
+	remove bool
+	update func(value CRDT) error
+}
+
+// Create a new map
+func NewMap() Map {
+	return Map{Clock: NewDVV(), Entries: map[Field]MapEntry{}}
+}
+
+// Create a new map whose dots read timestamps from the clock
+func NewMapWithClock(clock Clock) Map {
+	return Map{Clock: NewDVVWithClock(clock), Entries: map[Field]MapEntry{}}
+}
+
+// Update the counter of the field name
+func UpdateCounter(name string, fn func(*EMCounter)) MapOp {
+	return MapOp{field: Field{Name: name, Type: CounterField}, update: func(value CRDT) error {
+		fn(value.(*EMCounter))
+		return nil
+	}}
+}
+
+// Update the set of the field name
+func UpdateSet(name string, fn func(*ORSWOT) error) MapOp {
+	return MapOp{field: Field{Name: name, Type: SetField}, update: func(value CRDT) error {
+		return fn(value.(*ORSWOT))
+	}}
+}
+
+// Update the register of the field name
+func UpdateRegister(name string, fn func(*LWWReg)) MapOp {
+	return MapOp{field: Field{Name: name, Type: RegisterField}, update: func(value CRDT) error {
+		fn(value.(*LWWReg))
+		return nil
+	}}
+}
+
+// Update the flag of the field name
+func UpdateFlag(name string, fn func(*EWFlag)) MapOp {
+	return MapOp{field: Field{Name: name, Type: FlagField}, update: func(value CRDT) error {
+		fn(value.(*EWFlag))
+		return nil
+	}}
+}
+
+// Update the map of the field name
+func UpdateMap(name string, fn func(*Map) error) MapOp {
+	return MapOp{field: Field{Name: name, Type: MapField}, update: func(value CRDT) error {
+		return fn(value.(*Map))
+	}}
+}
+
+// Remove the field
+func RemoveField(field Field) MapOp {
+	return MapOp{field: field, remove: true}
+}
+
+// Return the value of every field: an int for counters, a []string for
+// sets, a string for registers, a bool for flags and a map for maps
+func (m Map) Value() map[Field]interface{} {
+	acc := map[Field]interface{}{}
+	for field, entry := range m.Entries {
+		acc[field] = entry.Value.ValueCRDT()
+	}
+	return acc
+}
+
+// Return a copy of the crdt of the field
+func (m Map) Get(field Field) (CRDT, bool) {
+	entry, ok := m.Entries[field]
+	if !ok {
+		return nil, false
+	}
+	return entry.Value.CloneCRDT(), true
+}
+
+// Apply the operations of the actor in order. Either every operation
+// is applied, or none is and the error of the failed one is returned.
+// Removing a field which is not present fails with ErrNotPresent.
+func (m *Map) Update(actor string, ops ...MapOp) error {
+	acc := m.Clone()
+	for _, op := range ops {
+		if op.remove {
+			if _, ok := acc.Entries[op.field]; !ok {
+				return ErrNotPresent
+			}
+			delete(acc.Entries, op.field)
+			continue
+		}
+		value := newEmbedded(op.field.Type)
+		if entry, ok := acc.Entries[op.field]; ok {
+			value = entry.Value
+		}
+		setParentClock(value, acc.Clock)
+		if err := op.update(value); err != nil {
+			return err
+		}
+		if clock, ok := parentClock(value); ok {
+			acc.Clock.Merge([]DVV{clock})
+		}
+		setParentClock(value, NewDVV())
+		acc.Entries[op.field] = MapEntry{Dots: newFlagDot(&acc.Clock, actor), Value: value}
+	}
+	*m = acc
+	return nil
+}
+
+// Merge two map to a single map. The dots of the fields are merged like
+// the dots of ORSWOT elements, and the crdts of the fields which stay
+// present are merged under the clocks of the maps.
+func (m_a *Map) Merge(m_b Map) {
+	entries := map[Field]MapEntry{}
+	fields := []Field{}
+	for field := range m_a.Entries {
+		fields = append(fields, field)
+	}
+	for field := range m_b.Entries {
+		if _, ok := m_a.Entries[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range fields {
+		entry_a, ok_a := m_a.Entries[field]
+		entry_b, ok_b := m_b.Entries[field]
+		dots := mergeDots(entry_a.Dots, m_a.Clock, entry_b.Dots, m_b.Clock)
+		if dots.Len() == 0 {
+			continue
+		}
+		value_a := newEmbedded(field.Type)
+		if ok_a {
+			value_a = entry_a.Value.CloneCRDT()
+		}
+		value_b := newEmbedded(field.Type)
+		if ok_b {
+			value_b = entry_b.Value.CloneCRDT()
+		}
+		entries[field] = MapEntry{Dots: dots, Value: mergeEmbedded(value_a, m_a.Clock, value_b, m_b.Clock)}
+	}
+	m_a.Clock.Merge([]DVV{m_b.Clock})
+	m_a.Entries = entries
+}
+
+// Compare two map for equality
+func (m_a Map) Equal(m_b Map) bool {
+	if !m_a.Clock.equal(m_b.Clock) || len(m_a.Entries) != len(m_b.Entries) {
+		return false
+	}
+	for field, entry_a := range m_a.Entries {
+		entry_b, ok := m_b.Entries[field]
+		if !ok || !entry_a.Dots.equal(entry_b.Dots) || !entry_a.Value.EqualCRDT(entry_b.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return a copy of the map
+func (m Map) Clone() Map {
+	entries := make(map[Field]MapEntry, len(m.Entries))
+	for field, entry := range m.Entries {
+		entries[field] = MapEntry{Dots: entry.Dots, Value: entry.Value.CloneCRDT()}
+	}
+	return Map{Clock: m.Clock, Entries: entries}
+}
+
+// ------------------- private functions -------------------
+
+// The fields ordered by name and type
+func sortedFields(entries map[Field]MapEntry) []Field {
+	acc := make([]Field, 0, len(entries))
+	for field := range entries {
+		acc = append(acc, field)
+	}
+	sort.Slice(acc, func(i, j int) bool {
+		if acc[i].Name != acc[j].Name {
+			return acc[i].Name < acc[j].Name
+		}
+		return acc[i].Type < acc[j].Type
+	})
+	return acc
+}
+
+func newEmbedded(t FieldType) CRDT {
+	switch t {
+	case CounterField:
+		counter := NewEMCounter()
+		return &counter
+	case SetField:
+		orswot := NewORSWOT()
+		return &orswot
+	case RegisterField:
+		reg := NewLWWReg()
+		return &reg
+	case FlagField:
+		flag := NewEWFlag()
+		return &flag
+	default:
+		m := NewMap()
+		return &m
+	}
+}
+
+// The crdts with dots are embedded with the clock of the map, so that
+// their dots are unique in the map and a missing dot seen by the map
+// is known to be removed. It is only set while the crdt is updated or
+// merged, and embedded crdts are kept with an empty clock otherwise.
+func setParentClock(value CRDT, clock DVV) {
+	switch value := value.(type) {
+	case *EMCounter:
+		value.Clock = clock
+	case *ORSWOT:
+		value.Clock = clock
+	case *EWFlag:
+		value.Clock = clock
+	case *Map:
+		value.Clock = clock
+	}
+}
+
+func parentClock(value CRDT) (DVV, bool) {
+	switch value := value.(type) {
+	case *EMCounter:
+		return value.Clock, true
+	case *ORSWOT:
+		return value.Clock, true
+	case *EWFlag:
+		return value.Clock, true
+	case *Map:
+		return value.Clock, true
+	}
+	return DVV{}, false
+}
+
+// Merge the crdt value_b of a map with clock_b into the crdt value_a
+// of a map with clock_a. A crdt which is missing on one side is merged
+// as an empty one, which resets the effects that side has seen.
+func mergeEmbedded(value_a CRDT, clock_a DVV, value_b CRDT, clock_b DVV) CRDT {
+	setParentClock(value_a, clock_a)
+	setParentClock(value_b, clock_b)
+	// both have the type of the field, so this can't fail
+	value_a.MergeCRDT(value_b)
+	setParentClock(value_a, NewDVV())
+	return value_a
+}
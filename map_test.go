@@ -0,0 +1,312 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewMap(t *testing.T) {
+	m := NewMap()
+	if len(m.Value()) != 0 {
+		t.Errorf("map.value should be empty %#v", m)
+	}
+}
+
+func TestMapUpdate(t *testing.T) {
+	m := NewMap()
+	err := m.Update("a",
+		UpdateCounter("visits", func(c *EMCounter) {
+			c.IncrementBy("a", 3)
+			c.Decrement("a")
+		}),
+		UpdateSet("tags", func(s *ORSWOT) error {
+			s.AddAll([]string{"red", "blue"}, "a")
+			return nil
+		}),
+		UpdateRegister("name", func(r *LWWReg) {
+			r.AssignByTS("alice", "a", 1)
+		}),
+		UpdateFlag("active", func(f *EWFlag) {
+			f.Enable("a")
+		}),
+		UpdateMap("address", func(inner *Map) error {
+			return inner.Update("a", UpdateRegister("city", func(r *LWWReg) {
+				r.AssignByTS("tokyo", "a", 1)
+			}))
+		}),
+	)
+	if err != nil {
+		t.Errorf("update should succeed %v", err)
+	}
+
+	expected := map[Field]interface{}{
+		{Name: "visits", Type: CounterField}: 2,
+		{Name: "tags", Type: SetField}:       []string{"blue", "red"},
+		{Name: "name", Type: RegisterField}:  "alice",
+		{Name: "active", Type: FlagField}:    true,
+		{Name: "address", Type: MapField}: map[Field]interface{}{
+			{Name: "city", Type: RegisterField}: "tokyo",
+		},
+	}
+	if !reflect.DeepEqual(m.Value(), expected) {
+		t.Errorf("map.value should be %#v but %#v", expected, m.Value())
+	}
+
+	// a dot for each of the five fields, and the embedded counter, set,
+	// flag and map draw theirs from the clock of the map
+	if m.Clock.GetCounter("a") != 11 {
+		t.Errorf("clock of a should be 11 but %d", m.Clock.GetCounter("a"))
+	}
+}
+
+func TestMapUpdateAtomic(t *testing.T) {
+	m := NewMap()
+	m.Update("a", UpdateSet("tags", func(s *ORSWOT) error {
+		s.Add("red", "a")
+		return nil
+	}))
+	before := m.Clone()
+
+	err := m.Update("a",
+		UpdateSet("tags", func(s *ORSWOT) error {
+			return s.Remove("red")
+		}),
+		RemoveField(Field{Name: "missing", Type: CounterField}),
+	)
+	if err != ErrNotPresent {
+		t.Errorf("removing a missing field should fail %v", err)
+	}
+
+	err = m.Update("a", UpdateSet("tags", func(s *ORSWOT) error {
+		s.Add("blue", "a")
+		return s.Remove("green")
+	}))
+	if err != ErrNotPresent {
+		t.Errorf("removing a missing element should fail %v", err)
+	}
+
+	if !m.Equal(before) {
+		t.Errorf("failed updates shouldn't change the map %#v", m)
+	}
+}
+
+func TestMapMerge(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateRegister("name", func(r *LWWReg) {
+		r.AssignByTS("alice", "a", 1)
+	}))
+
+	m2 := NewMap()
+	m2.Update("b", UpdateCounter("visits", func(c *EMCounter) {
+		c.Increment("b")
+	}))
+
+	merged1 := m1.Clone()
+	merged1.Merge(m2)
+	merged2 := m2.Clone()
+	merged2.Merge(m1)
+
+	if !merged1.Equal(merged2) {
+		t.Errorf("merge should be commutative %#v %#v", merged1, merged2)
+	}
+
+	expected := map[Field]interface{}{
+		{Name: "name", Type: RegisterField}:  "alice",
+		{Name: "visits", Type: CounterField}: 1,
+	}
+	if !reflect.DeepEqual(merged1.Value(), expected) {
+		t.Errorf("map.value should be %#v but %#v", expected, merged1.Value())
+	}
+}
+
+func TestMapObservedRemove(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateFlag("active", func(f *EWFlag) {
+		f.Enable("a")
+	}))
+
+	m2 := m1.Clone()
+	if err := m2.Update("b", RemoveField(Field{Name: "active", Type: FlagField})); err != nil {
+		t.Errorf("active should be removed %v", err)
+	}
+
+	m1.Merge(m2)
+	if len(m1.Value()) != 0 {
+		t.Errorf("observed remove should win %#v", m1.Value())
+	}
+}
+
+func TestMapResetRemove(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateSet("tags", func(s *ORSWOT) error {
+		s.AddAll([]string{"red", "blue"}, "a")
+		return nil
+	}))
+
+	m2 := m1.Clone()
+
+	// a removes the field while b concurrently adds to it
+	m1.Update("a", RemoveField(Field{Name: "tags", Type: SetField}))
+	m2.Update("b", UpdateSet("tags", func(s *ORSWOT) error {
+		s.Add("green", "b")
+		return nil
+	}))
+
+	merged1 := m1.Clone()
+	merged1.Merge(m2)
+	merged2 := m2.Clone()
+	merged2.Merge(m1)
+
+	expected := map[Field]interface{}{
+		{Name: "tags", Type: SetField}: []string{"green"},
+	}
+	if !reflect.DeepEqual(merged1.Value(), expected) {
+		t.Errorf("only the concurrent add should survive %#v", merged1.Value())
+	}
+
+	if !merged1.Equal(merged2) {
+		t.Errorf("merge should be commutative %#v %#v", merged1, merged2)
+	}
+}
+
+func TestMapCounterResetRemove(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateCounter("visits", func(c *EMCounter) {
+		c.IncrementBy("a", 5)
+	}))
+
+	m2 := m1.Clone()
+
+	m1.Update("a", RemoveField(Field{Name: "visits", Type: CounterField}))
+	m2.Update("b", UpdateCounter("visits", func(c *EMCounter) {
+		c.IncrementBy("b", 2)
+	}))
+
+	merged1 := m1.Clone()
+	merged1.Merge(m2)
+	merged2 := m2.Clone()
+	merged2.Merge(m1)
+
+	expected := map[Field]interface{}{
+		{Name: "visits", Type: CounterField}: 2,
+	}
+	if !reflect.DeepEqual(merged1.Value(), expected) {
+		t.Errorf("only the concurrent increment should survive %#v", merged1.Value())
+	}
+
+	if !merged1.Equal(merged2) {
+		t.Errorf("merge should be commutative %#v %#v", merged1, merged2)
+	}
+}
+
+func TestMapCounterResetRemoveSameActor(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateCounter("visits", func(c *EMCounter) {
+		c.IncrementBy("a", 5)
+	}))
+
+	m2 := m1.Clone()
+
+	// b removes the counter while a concurrently increments it again
+	m2.Update("b", RemoveField(Field{Name: "visits", Type: CounterField}))
+	m1.Update("a", UpdateCounter("visits", func(c *EMCounter) {
+		c.IncrementBy("a", 2)
+	}))
+
+	merged1 := m1.Clone()
+	merged1.Merge(m2)
+	merged2 := m2.Clone()
+	merged2.Merge(m1)
+
+	expected := map[Field]interface{}{
+		{Name: "visits", Type: CounterField}: 7,
+	}
+	// the entry of a carries the totals of a over the remove
+	if !reflect.DeepEqual(merged1.Value(), expected) {
+		t.Errorf("the entry of a should survive the remove %#v", merged1.Value())
+	}
+
+	if !merged1.Equal(merged2) {
+		t.Errorf("merge should be commutative %#v %#v", merged1, merged2)
+	}
+}
+
+func TestMapNestedResetRemove(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateMap("address", func(inner *Map) error {
+		return inner.Update("a",
+			UpdateRegister("city", func(r *LWWReg) {
+				r.AssignByTS("tokyo", "a", 1)
+			}),
+			UpdateFlag("verified", func(f *EWFlag) {
+				f.Enable("a")
+			}))
+	}))
+
+	m2 := m1.Clone()
+
+	m1.Update("a", RemoveField(Field{Name: "address", Type: MapField}))
+	m2.Update("b", UpdateMap("address", func(inner *Map) error {
+		return inner.Update("b", UpdateRegister("zip", func(r *LWWReg) {
+			r.AssignByTS("100-0001", "b", 2)
+		}))
+	}))
+
+	m1.Merge(m2)
+	expected := map[Field]interface{}{
+		{Name: "address", Type: MapField}: map[Field]interface{}{
+			{Name: "zip", Type: RegisterField}: "100-0001",
+		},
+	}
+	if !reflect.DeepEqual(m1.Value(), expected) {
+		t.Errorf("only the concurrent update should survive %#v", m1.Value())
+	}
+}
+
+func TestMapReAdd(t *testing.T) {
+	m1 := NewMap()
+	m1.Update("a", UpdateSet("tags", func(s *ORSWOT) error {
+		s.Add("red", "a")
+		return nil
+	}))
+	m2 := m1.Clone()
+
+	// a removes the field and adds it again without its old elements
+	m1.Update("a", RemoveField(Field{Name: "tags", Type: SetField}))
+	m1.Update("a", UpdateSet("tags", func(s *ORSWOT) error {
+		s.Add("blue", "a")
+		return nil
+	}))
+
+	m2.Merge(m1)
+	expected := map[Field]interface{}{
+		{Name: "tags", Type: SetField}: []string{"blue"},
+	}
+	if !reflect.DeepEqual(m2.Value(), expected) {
+		t.Errorf("removed elements shouldn't come back %#v", m2.Value())
+	}
+}
+
+func TestMapGet(t *testing.T) {
+	m := NewMap()
+	m.Update("a", UpdateRegister("name", func(r *LWWReg) {
+		r.AssignByTS("alice", "a", 10)
+	}))
+
+	value, ok := m.Get(Field{Name: "name", Type: RegisterField})
+	if !ok || value.(*LWWReg).Timestamp != 10 {
+		t.Errorf("name should be the register %#v", value)
+	}
+
+	value.(*LWWReg).AssignByTS("bob", "a", 11)
+	if m.Value()[Field{Name: "name", Type: RegisterField}] != "alice" {
+		t.Error("updating the copy shouldn't change the map")
+	}
+
+	if _, ok := m.Get(Field{Name: "name", Type: CounterField}); ok {
+		t.Error("fields with another type should be different fields")
+	}
+}
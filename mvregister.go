@@ -0,0 +1,144 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// A state-based multi value register. Concurrent writes are not
+// thrown away but kept as siblings until a write which has seen
+// them all supersedes them.
+
+package dt
+
+import (
+	"sort"
+)
+
+// A value with the dot of its write and the causal context the write
+// was made with. A write supersedes the values whose dots its context
+// has seen, as in a dvvset.
+type MVEntry struct {
+	Dot     Dot
+	Context DVV
+	Value   string
+}
+
+type MVRegister struct {
+	Entries []MVEntry
+	clock   Clock
+}
+
+// Create a new empty mvregister
+func NewMVRegister() MVRegister {
+	return MVRegister{}
+}
+
+// Create a new empty mvregister whose dots read timestamps from the clock
+func NewMVRegisterWithClock(clock Clock) MVRegister {
+	return MVRegister{clock: clock}
+}
+
+// Return the siblings, sorted, and the causal context which the next
+// Assign should be made with to supersede all of them
+func (reg MVRegister) Values() ([]string, DVV) {
+	acc := []string{}
+	seen := map[string]bool{}
+	context := NewDVV()
+	for _, entry := range reg.Entries {
+		if !seen[entry.Value] {
+			seen[entry.Value] = true
+			acc = append(acc, entry.Value)
+		}
+		context.Merge([]DVV{entry.Context, {vector: []Dot{entry.Dot}}})
+	}
+	sort.Strings(acc)
+	return acc, context
+}
+
+// Assign a value written by the actor, which has read the context.
+// The siblings the context has seen are superseded, and the others
+// are kept as siblings of the new value.
+func (reg *MVRegister) Assign(value string, context DVV, actor string) {
+	// the dot of the write must be new to every sibling
+	_, seen := reg.Values()
+	clock := NewDVVWithClock(reg.clock)
+	clock.vector = []Dot{{node: actor, counter: seen.GetCounter(actor)}}
+	clock.Merge([]DVV{context})
+	clock.Increment(actor)
+	dot, _ := clock.GetDot(actor)
+
+	entries := []MVEntry{}
+	for _, entry := range reg.Entries {
+		if !coversDot(context, entry.Dot) {
+			entries = append(entries, entry)
+		}
+	}
+	reg.Entries = append(entries, MVEntry{Dot: *dot, Context: context, Value: value})
+}
+
+// Merge two mvregister to a single mvregister. Siblings of either side
+// are kept unless the context of a sibling of the other side has seen them.
+func (reg_a *MVRegister) Merge(reg_b MVRegister) {
+	all := append(append([]MVEntry{}, reg_a.Entries...), reg_b.Entries...)
+	entries := []MVEntry{}
+	for i, entry := range all {
+		keep := true
+		for j, other := range all {
+			same := other.Dot.node == entry.Dot.node && other.Dot.counter == entry.Dot.counter
+			if coversDot(other.Context, entry.Dot) || (same && j < i) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			entries = append(entries, entry)
+		}
+	}
+	reg_a.Entries = entries
+}
+
+// Compare two mvregister for equality, as sets of siblings
+func (reg_a MVRegister) Equal(reg_b MVRegister) bool {
+	if len(reg_a.Entries) != len(reg_b.Entries) {
+		return false
+	}
+	for _, entry_a := range reg_a.Entries {
+		found := false
+		for _, entry_b := range reg_b.Entries {
+			if entry_a.Value == entry_b.Value && entry_a.Dot == entry_b.Dot && entry_a.Context.equal(entry_b.Context) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Return a copy of the register
+func (reg MVRegister) Clone() MVRegister {
+	return MVRegister{Entries: append([]MVEntry(nil), reg.Entries...), clock: reg.clock}
+}
+
+// ------------------- private functions -------------------
+
+// The siblings sorted by dot and then value, so that equal registers
+// encode the same
+func (reg MVRegister) sortedEntries() []MVEntry {
+	acc := append([]MVEntry(nil), reg.Entries...)
+	sort.Slice(acc, func(i, j int) bool {
+		a, b := acc[i], acc[j]
+		if a.Dot.node != b.Dot.node {
+			return a.Dot.node < b.Dot.node
+		}
+		if a.Dot.counter != b.Dot.counter {
+			return a.Dot.counter < b.Dot.counter
+		}
+		return a.Value < b.Value
+	})
+	return acc
+}
+
+// Whether the context has seen the dot
+func coversDot(context DVV, dot Dot) bool {
+	return context.GetCounter(dot.node) >= dot.counter
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewMVRegister(t *testing.T) {
+	reg := NewMVRegister()
+	values, context := reg.Values()
+	if len(values) != 0 || context.Len() != 0 {
+		t.Errorf("mvregister should be empty %#v", reg)
+	}
+}
+
+func TestMVRegisterAssign(t *testing.T) {
+	reg := NewMVRegister()
+	reg.Assign("value1", NewDVV(), "a")
+	_, context := reg.Values()
+	reg.Assign("value2", context, "a")
+
+	values, _ := reg.Values()
+	if !reflect.DeepEqual(values, []string{"value2"}) {
+		t.Errorf("value2 should supersede value1 %#v", values)
+	}
+
+	// a write without the context of value2 is concurrent with it
+	reg.Assign("value3", context, "a")
+	values, context = reg.Values()
+	if !reflect.DeepEqual(values, []string{"value2", "value3"}) {
+		t.Errorf("value2 and value3 should be siblings %#v", values)
+	}
+
+	merged := reg.Clone()
+	merged.Merge(reg.Clone())
+	if merged_values, _ := merged.Values(); !reflect.DeepEqual(merged_values, values) {
+		t.Errorf("merge with itself should keep the siblings %#v", merged_values)
+	}
+	other := NewMVRegister()
+	other.Merge(reg)
+	if other_values, _ := other.Values(); !reflect.DeepEqual(other_values, values) {
+		t.Errorf("merge into another replica should keep the siblings %#v", other_values)
+	}
+
+	reg.Assign("value4", context, "b")
+	values, _ = reg.Values()
+	if !reflect.DeepEqual(values, []string{"value4"}) {
+		t.Errorf("value4 should supersede the siblings %#v", values)
+	}
+}
+
+func TestMVRegisterMerge(t *testing.T) {
+	reg1 := NewMVRegister()
+	reg1.Assign("value1", NewDVV(), "a")
+
+	reg2 := NewMVRegister()
+	reg2.Merge(reg1)
+	_, context := reg2.Values()
+
+	reg1.Assign("value2", context, "a")
+	reg2.Assign("value3", context, "b")
+
+	merged1 := reg1
+	merged1.Merge(reg2)
+	merged2 := reg2
+	merged2.Merge(reg1)
+
+	if !merged1.Equal(merged2) {
+		t.Errorf("merge should be commutative %#v %#v", merged1, merged2)
+	}
+
+	values, context := merged1.Values()
+	if !reflect.DeepEqual(values, []string{"value2", "value3"}) {
+		t.Errorf("concurrent writes should be siblings %#v", values)
+	}
+
+	merged1.Merge(merged2)
+	if len(merged1.Entries) != 2 {
+		t.Errorf("merge should be idempotent %#v", merged1)
+	}
+
+	merged1.Assign("value4", context, "a")
+	merged2.Merge(merged1)
+	values, _ = merged2.Values()
+	if !reflect.DeepEqual(values, []string{"value4"}) {
+		t.Errorf("value4 should supersede the siblings %#v", values)
+	}
+}
+
+func TestMVRegisterWithClock(t *testing.T) {
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	reg := NewMVRegisterWithClock(clock)
+	reg.Assign("value1", NewDVV(), "a")
+	clock.Advance(time.Second)
+	_, context := reg.Values()
+	reg.Assign("value2", context, "a")
+
+	if ts, _ := reg.Entries[0].Context.GetTimestamp("a"); ts != 1600000000 {
+		t.Errorf("value1 should be written at the time of the clock %d", ts)
+	}
+	if reg.Entries[0].Dot.timestamp != 1600000001 {
+		t.Errorf("value2 should be written at the time of the clock %#v", reg.Entries[0].Dot)
+	}
+}
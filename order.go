@@ -0,0 +1,68 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// A total order on elements and values of any type, so that values are
+// returned sorted and lww ties are broken the same way on every replica.
+// The order is the same on every replica only for strings, byte slices,
+// booleans, numbers, and structs and arrays of them. Pointers, maps,
+// chans and funcs are ordered by their addresses in this process, so
+// sets and registers of them don't have a canonical encoding.
+
+package dt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ------------------- private functions -------------------
+
+// Strings, byte slices, booleans and numbers of every kind are ordered
+// as usual when both values have the same dynamic type. Values of
+// different types are ordered by type name, and values of other types
+// by their Go syntax representation. It holds the address of pointers,
+// maps, chans and funcs, so their order differs between processes.
+func lessValue[V any](a V, b V) bool {
+	if a, ok := any(a).(string); ok {
+		if b, ok := any(b).(string); ok {
+			return a < b
+		}
+	}
+	va, vb := reflect.ValueOf(any(a)), reflect.ValueOf(any(b))
+	if !va.IsValid() || !vb.IsValid() || va.Type() != vb.Type() {
+		if ta, tb := fmt.Sprintf("%T", a), fmt.Sprintf("%T", b); ta != tb {
+			return ta < tb
+		}
+		return fmt.Sprintf("%#v", a) < fmt.Sprintf("%#v", b)
+	}
+	switch va.Kind() {
+	case reflect.String:
+		return va.String() < vb.String()
+	case reflect.Bool:
+		return !va.Bool() && vb.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return va.Int() < vb.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return va.Uint() < vb.Uint()
+	case reflect.Float32, reflect.Float64:
+		return va.Float() < vb.Float()
+	case reflect.Slice:
+		if va.Type().Elem().Kind() == reflect.Uint8 {
+			return bytes.Compare(va.Bytes(), vb.Bytes()) < 0
+		}
+	}
+	return fmt.Sprintf("%#v", a) < fmt.Sprintf("%#v", b)
+}
+
+// sort the values in place
+func sortValues[V any](values []V) {
+	if strings, ok := any(values).([]string); ok {
+		sort.Strings(strings)
+		return
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return lessValue(values[i], values[j])
+	})
+}
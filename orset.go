@@ -4,65 +4,218 @@ package dt
 
 import (
 	"errors"
-	"math"
-	"math/rand"
+	"sort"
 )
 
-type Token struct {
-	Tag       int
-	IsRemoved bool
-}
+// Returned when removing an element which is not in the set
+var ErrNotPresent = errors.New("precondition: the elem doesn't exist")
+
+// Every tag an element has been added with, mapped to whether
+// the tag has been removed. A tag is the (actor, counter) dot
+// of the add, so tags are unique without any coordination.
+type Tokens map[Dot]bool
 
-type ORSet struct {
-	Set map[string]Token
+// Clock is the version vector the tags of this replica are drawn from
+type ORSetOf[T comparable] struct {
+	Clock DVV
+	Set   map[T]Tokens
 }
 
+// An ORSet of strings
+type ORSet = ORSetOf[string]
+
 // Create a new ORSet
 func NewOrset() ORSet {
-	s := map[string]Token{}
-	return ORSet{Set: s}
+	return NewOrsetOf[string]()
+}
+
+// Create a new ORSet whose version vector reads timestamps from the
+// clock. Tags are made without timestamps.
+func NewOrsetWithClock(clock Clock) ORSet {
+	return NewOrsetOfWithClock[string](clock)
+}
+
+// Create a new ORSet of elements of type T
+func NewOrsetOf[T comparable]() ORSetOf[T] {
+	s := map[T]Tokens{}
+	return ORSetOf[T]{Clock: NewDVV(), Set: s}
+}
+
+// Create a new ORSet of elements of type T whose version vector reads
+// timestamps from the clock. Tags are made without timestamps.
+func NewOrsetOfWithClock[T comparable](clock Clock) ORSetOf[T] {
+	s := map[T]Tokens{}
+	return ORSetOf[T]{Clock: NewDVVWithClock(clock), Set: s}
 }
 
 // Return values which hasn't removed only
-func (orset ORSet) Value() []string {
-	acc := []string{}
-	for value, token := range orset.Set {
-		if !token.IsRemoved {
+func (orset ORSetOf[T]) Value() []T {
+	acc := []T{}
+	for value, tokens := range orset.Set {
+		if tokens.isAlive() {
 			acc = append(acc, value)
 		}
 	}
+	sortValues(acc)
 	return acc
 }
 
 // Return values which has removed
-func (orset ORSet) RemovedValue() []string {
-	acc := []string{}
-	for value, token := range orset.Set {
-		if token.IsRemoved {
+func (orset ORSetOf[T]) RemovedValue() []T {
+	acc := []T{}
+	for value, tokens := range orset.Set {
+		if !tokens.isAlive() {
 			acc = append(acc, value)
 		}
 	}
+	sortValues(acc)
 	return acc
 }
 
-func (orset ORSet) Token(elem string) (Token, error) {
-	if token, ok := orset.Set[elem]; ok {
-		return token, nil
+func (orset ORSetOf[T]) Tokens(elem T) (Tokens, error) {
+	if tokens, ok := orset.Set[elem]; ok {
+		return tokens, nil
 	}
-	return Token{}, errors.New("the elem doesn't exist")
+	return Tokens{}, errors.New("the elem doesn't exist")
 }
 
-func (orset ORSet) Lookup(elem string) bool {
+func (orset ORSetOf[T]) Lookup(elem T) bool {
 	if _, ok := orset.Set[elem]; ok {
 		return true
 	}
 	return false
 }
 
+// Add an element to the set with a fresh tag made by the actor.
+// Concurrent adds of the same element keep their own tags.
+func (orset *ORSetOf[T]) Add(elem T, actor string) {
+	addTag(orset.Set, elem, nextTag(&orset.Clock, actor))
+}
+
+// Remove an element by marking every tag observed for it as removed.
+// Tags added concurrently on other replicas are not observed here,
+// so those adds win after merge.
+func (orset *ORSetOf[T]) Remove(elem T) error {
+	tokens, ok := orset.Set[elem]
+	if !ok || !tokens.isAlive() {
+		return ErrNotPresent
+	}
+	tokens.removeAll()
+	return nil
+}
+
+// Merge two orset to a single orset. Tags are unioned per element,
+// and a tag is removed if either side has removed it.
+func (orset_a *ORSetOf[T]) Merge(orset_b ORSetOf[T]) {
+	orset_a.Clock.Merge([]DVV{orset_b.Clock})
+	mergeTokens(orset_a.Set, orset_b.Set)
+}
+
+// Compare two orset for equality
+func (orset_a ORSetOf[T]) Equal(orset_b ORSetOf[T]) bool {
+	return orset_a.Clock.equal(orset_b.Clock) && equalTokens(orset_a.Set, orset_b.Set)
+}
+
+// Return a copy of the set
+func (orset ORSetOf[T]) Clone() ORSetOf[T] {
+	return ORSetOf[T]{Clock: orset.Clock, Set: cloneTokens(orset.Set)}
+}
+
 // private functions
 
-func unique() int {
-	s := rand.NewSource(int64(rand.Int63()))
-	r := rand.New(s)
-	return r.Intn(math.MaxInt64)
+// an element is alive while at least one of its tags is not removed
+func (tokens Tokens) isAlive() bool {
+	for _, removed := range tokens {
+		if !removed {
+			return true
+		}
+	}
+	return false
+}
+
+// the tags ordered by node, counter and timestamp
+func (tokens Tokens) sorted() []Dot {
+	acc := make([]Dot, 0, len(tokens))
+	for tag := range tokens {
+		acc = append(acc, tag)
+	}
+	sort.Slice(acc, func(i, j int) bool {
+		if acc[i].node != acc[j].node {
+			return acc[i].node < acc[j].node
+		}
+		if acc[i].counter != acc[j].counter {
+			return acc[i].counter < acc[j].counter
+		}
+		return acc[i].timestamp < acc[j].timestamp
+	})
+	return acc
+}
+
+// mark every tag as removed
+func (tokens Tokens) removeAll() {
+	for tag := range tokens {
+		tokens[tag] = true
+	}
+}
+
+// add a live tag to the tokens of the element
+func addTag[T comparable](set map[T]Tokens, elem T, tag Dot) {
+	tokens, ok := set[elem]
+	if !ok {
+		tokens = Tokens{}
+		set[elem] = tokens
+	}
+	tokens[tag] = false
+}
+
+// union the tags of set_b into set_a, a tag is removed if either side removed it
+func mergeTokens[T comparable](set_a map[T]Tokens, set_b map[T]Tokens) {
+	for elem, tokens_b := range set_b {
+		tokens_a, ok := set_a[elem]
+		if !ok {
+			tokens_a = Tokens{}
+			set_a[elem] = tokens_a
+		}
+		for tag, removed := range tokens_b {
+			tokens_a[tag] = tokens_a[tag] || removed
+		}
+	}
+}
+
+// draw the next dot of the actor from the clock. timestamps are left
+// out so the same add has the same tag on every replica.
+func nextTag(clock *DVV, actor string) Dot {
+	clock.Increment(actor)
+	return Dot{node: actor, counter: clock.GetCounter(actor)}
+}
+
+// copy the tokens of every element, they are updated in place
+func cloneTokens[T comparable](set map[T]Tokens) map[T]Tokens {
+	acc := map[T]Tokens{}
+	for elem, tokens := range set {
+		acc[elem] = Tokens{}
+		for tag, removed := range tokens {
+			acc[elem][tag] = removed
+		}
+	}
+	return acc
+}
+
+// true if both sets have the same tags for every element
+func equalTokens[T comparable](set_a map[T]Tokens, set_b map[T]Tokens) bool {
+	if len(set_a) != len(set_b) {
+		return false
+	}
+	for elem, tokens_a := range set_a {
+		tokens_b, ok := set_b[elem]
+		if !ok || len(tokens_a) != len(tokens_b) {
+			return false
+		}
+		for tag, removed := range tokens_a {
+			if other, ok := tokens_b[tag]; !ok || other != removed {
+				return false
+			}
+		}
+	}
+	return true
 }
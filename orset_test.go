@@ -0,0 +1,141 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewOrset(t *testing.T) {
+	orset := NewOrset()
+	if len(orset.Value()) != 0 {
+		t.Errorf("orset.value should be empty %#v", orset)
+	}
+}
+
+func TestORSetAdd(t *testing.T) {
+	orset := NewOrset()
+	orset.Add("value1", "a")
+	orset.Add("value2", "a")
+	orset.Add("value1", "b")
+
+	if !reflect.DeepEqual(orset.Value(), []string{"value1", "value2"}) {
+		t.Errorf("orset.value should be value1 and value2 %#v", orset.Value())
+	}
+
+	if tokens, _ := orset.Tokens("value1"); len(tokens) != 2 {
+		t.Errorf("value1 should have two tags %#v", tokens)
+	}
+}
+
+func TestORSetRemove(t *testing.T) {
+	orset := NewOrset()
+	orset.Add("value1", "a")
+	orset.Add("value2", "a")
+
+	if err := orset.Remove("value1"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+
+	if !reflect.DeepEqual(orset.Value(), []string{"value2"}) {
+		t.Errorf("orset.value should be value2 %#v", orset.Value())
+	}
+
+	if !reflect.DeepEqual(orset.RemovedValue(), []string{"value1"}) {
+		t.Errorf("orset.removed_value should be value1 %#v", orset.RemovedValue())
+	}
+
+	if err := orset.Remove("value1"); err != ErrNotPresent {
+		t.Errorf("removing value1 twice should fail %v", err)
+	}
+
+	if err := orset.Remove("value3"); err != ErrNotPresent {
+		t.Errorf("removing value3 should fail %v", err)
+	}
+}
+
+func TestORSetMerge(t *testing.T) {
+	orset1 := NewOrset()
+	orset2 := NewOrset()
+	orset1.Add("value1", "a")
+	orset2.Add("value2", "b")
+	orset1.Merge(orset2)
+	orset2.Merge(orset1)
+
+	expected := []string{"value1", "value2"}
+	if !reflect.DeepEqual(orset1.Value(), expected) {
+		t.Errorf("orset1.value should be %#v but %#v", expected, orset1.Value())
+	}
+
+	if !reflect.DeepEqual(orset2.Value(), expected) {
+		t.Errorf("orset2.value should be %#v but %#v", expected, orset2.Value())
+	}
+}
+
+func TestORSetConcurrentAddWins(t *testing.T) {
+	orset1 := NewOrset()
+	orset1.Add("value1", "a")
+
+	orset2 := NewOrset()
+	orset2.Merge(orset1)
+
+	// a removes value1 while b concurrently adds it again
+	if err := orset1.Remove("value1"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+	orset2.Add("value1", "b")
+
+	orset1.Merge(orset2)
+	orset2.Merge(orset1)
+
+	if !reflect.DeepEqual(orset1.Value(), []string{"value1"}) {
+		t.Errorf("concurrent add should win %#v", orset1.Value())
+	}
+
+	if !reflect.DeepEqual(orset1.Set, orset2.Set) {
+		t.Errorf("orset1 should be same with orset2 %#v %#v", orset1, orset2)
+	}
+}
+
+func TestORSetTags(t *testing.T) {
+	orset1 := NewOrset()
+	orset1.Add("value1", "a")
+	orset1.Add("value1", "a")
+
+	orset2 := NewOrset()
+	orset2.Merge(orset1)
+	orset2.Add("value1", "b")
+	orset2.Add("value1", "a")
+
+	expected := Tokens{
+		Dot{node: "a", counter: 1}: false,
+		Dot{node: "a", counter: 2}: false,
+		Dot{node: "a", counter: 3}: false,
+		Dot{node: "b", counter: 1}: false,
+	}
+	if tokens, _ := orset2.Tokens("value1"); !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("tags should be dots of the actors %#v", tokens)
+	}
+}
+
+func TestOrsetOf(t *testing.T) {
+	type user struct {
+		id   int
+		name string
+	}
+	orset1 := NewOrsetOf[user]()
+	orset1.Add(user{2, "bob"}, "a")
+	orset1.Add(user{1, "alice"}, "a")
+	orset2 := orset1.Clone()
+
+	orset1.Remove(user{2, "bob"})
+	orset2.Add(user{3, "carol"}, "b")
+	orset1.Merge(orset2)
+
+	expected := []user{{1, "alice"}, {3, "carol"}}
+	if !reflect.DeepEqual(orset1.Value(), expected) {
+		t.Errorf("orset.value should be %#v but %#v", expected, orset1.Value())
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+// An optimized observed remove set without tombstones
+// based on riak_dt_orswot
+
+package dt
+
+import (
+	"sort"
+)
+
+// Each element carries the dots of the adds which made it present,
+// while the set wide version vector records every dot ever seen.
+// A dot that is missing from an element but covered by the clock
+// has been removed, so removed elements need not be kept around.
+type ORSWOT struct {
+	Clock   DVV
+	Entries map[string]DVV
+}
+
+// Create a new ORSWOT
+func NewORSWOT() ORSWOT {
+	return ORSWOT{Clock: NewDVV(), Entries: map[string]DVV{}}
+}
+
+// Create a new ORSWOT whose dots read timestamps from the clock
+func NewORSWOTWithClock(clock Clock) ORSWOT {
+	return ORSWOT{Clock: NewDVVWithClock(clock), Entries: map[string]DVV{}}
+}
+
+// Return the elements of the set
+func (orswot ORSWOT) Value() []string {
+	acc := []string{}
+	for elem := range orswot.Entries {
+		acc = append(acc, elem)
+	}
+	sort.Strings(acc)
+	return acc
+}
+
+// return true if the element exists in the set
+func (orswot ORSWOT) Lookup(elem string) bool {
+	_, ok := orswot.Entries[elem]
+	return ok
+}
+
+// Add an element with a new dot from the actor. The new dot replaces
+// the dots the element had, since it descends all of them.
+func (orswot *ORSWOT) Add(elem string, actor string) {
+	orswot.Clock.Increment(actor)
+	dot, _ := orswot.Clock.GetDot(actor)
+	dots := NewDVV()
+	dots.vector = []Dot{*dot}
+	orswot.Entries[elem] = dots
+}
+
+// Add each element in turn
+func (orswot *ORSWOT) AddAll(elems []string, actor string) {
+	for _, elem := range elems {
+		orswot.Add(elem, actor)
+	}
+}
+
+// Remove an element. Nothing is kept for it, the clock alone tells
+// the other replicas that its dots have been observed.
+func (orswot *ORSWOT) Remove(elem string) error {
+	if !orswot.Lookup(elem) {
+		return ErrNotPresent
+	}
+	delete(orswot.Entries, elem)
+	return nil
+}
+
+// Remove all the elements, or none of them if any is not present
+func (orswot *ORSWOT) RemoveAll(elems []string) error {
+	for _, elem := range elems {
+		if !orswot.Lookup(elem) {
+			return ErrNotPresent
+		}
+	}
+	for _, elem := range elems {
+		delete(orswot.Entries, elem)
+	}
+	return nil
+}
+
+// Merge two orswot to a single orswot. Dots that both sides have are kept,
+// and dots only one side has are kept when the other side has not seen them.
+func (orswot_a *ORSWOT) Merge(orswot_b ORSWOT) {
+	entries := map[string]DVV{}
+	for elem, dots_a := range orswot_a.Entries {
+		dots_b := orswot_b.Entries[elem]
+		dots := mergeDots(dots_a, orswot_a.Clock, dots_b, orswot_b.Clock)
+		if dots.Len() > 0 {
+			entries[elem] = dots
+		}
+	}
+	for elem, dots_b := range orswot_b.Entries {
+		if _, ok := orswot_a.Entries[elem]; ok {
+			continue
+		}
+		dots := mergeDots(NewDVV(), orswot_a.Clock, dots_b, orswot_b.Clock)
+		if dots.Len() > 0 {
+			entries[elem] = dots
+		}
+	}
+	orswot_a.Clock.Merge([]DVV{orswot_b.Clock})
+	orswot_a.Entries = entries
+}
+
+// Compare two orswot for equality
+func (orswot_a ORSWOT) Equal(orswot_b ORSWOT) bool {
+	if !orswot_a.Clock.equal(orswot_b.Clock) {
+		return false
+	}
+	if len(orswot_a.Entries) != len(orswot_b.Entries) {
+		return false
+	}
+	for elem, dots_a := range orswot_a.Entries {
+		dots_b, ok := orswot_b.Entries[elem]
+		if !ok || !dots_a.equal(dots_b) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return a copy of the set. DVVs are never modified in place
+// and need not be copied.
+func (orswot ORSWOT) Clone() ORSWOT {
+	acc := ORSWOT{Clock: orswot.Clock, Entries: map[string]DVV{}}
+	for elem, dots := range orswot.Entries {
+		acc.Entries[elem] = dots
+	}
+	return acc
+}
+
+// ------------------- private functions -------------------
+
+// Merge the dots of an element seen under clock_a and clock_b.
+func mergeDots(dots_a DVV, clock_a DVV, dots_b DVV, clock_b DVV) DVV {
+	acc := NewDVV()
+	for _, dot := range dots_a.vector {
+		if dots_b.GetCounter(dot.node) == dot.counter {
+			acc.vector = append(acc.vector, dot)
+		} else if clock_b.GetCounter(dot.node) < dot.counter {
+			acc.vector = append(acc.vector, dot)
+		}
+	}
+	for _, dot := range dots_b.vector {
+		if dots_a.GetCounter(dot.node) == dot.counter {
+			continue
+		} else if clock_a.GetCounter(dot.node) < dot.counter {
+			acc.vector = append(acc.vector, dot)
+		}
+	}
+	sort.Sort(acc)
+	return acc
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewORSWOT(t *testing.T) {
+	orswot := NewORSWOT()
+	if len(orswot.Value()) != 0 {
+		t.Errorf("orswot.value should be empty %#v", orswot)
+	}
+}
+
+func TestORSWOTAdd(t *testing.T) {
+	orswot := NewORSWOT()
+	orswot.Add("value1", "a")
+	orswot.AddAll([]string{"value2", "value1"}, "a")
+
+	if !reflect.DeepEqual(orswot.Value(), []string{"value1", "value2"}) {
+		t.Errorf("orswot.value should be value1 and value2 %#v", orswot.Value())
+	}
+
+	if orswot.Clock.GetCounter("a") != 3 {
+		t.Errorf("clock of a should be 3 but %d", orswot.Clock.GetCounter("a"))
+	}
+
+	dots := orswot.Entries["value1"]
+	if dots.Len() != 1 || dots.GetCounter("a") != 3 {
+		t.Errorf("value1 should only have the latest dot %#v", dots)
+	}
+}
+
+func TestORSWOTRemove(t *testing.T) {
+	orswot := NewORSWOT()
+	orswot.AddAll([]string{"value1", "value2", "value3"}, "a")
+
+	if err := orswot.Remove("value1"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+
+	if err := orswot.Remove("value1"); err != ErrNotPresent {
+		t.Errorf("removing value1 twice should fail %v", err)
+	}
+
+	if err := orswot.RemoveAll([]string{"value2", "value4"}); err != ErrNotPresent {
+		t.Errorf("removing value4 should fail %v", err)
+	}
+
+	if !reflect.DeepEqual(orswot.Value(), []string{"value2", "value3"}) {
+		t.Errorf("failed remove_all shouldn't remove anything %#v", orswot.Value())
+	}
+
+	if err := orswot.RemoveAll([]string{"value2", "value3"}); err != nil {
+		t.Errorf("value2 and value3 should be removed %v", err)
+	}
+
+	if len(orswot.Entries) != 0 {
+		t.Errorf("removed elements shouldn't leave tombstones %#v", orswot.Entries)
+	}
+}
+
+func TestORSWOTMerge(t *testing.T) {
+	orswot1 := NewORSWOT()
+	orswot2 := NewORSWOT()
+	orswot1.Add("value1", "a")
+	orswot2.Add("value2", "b")
+	orswot1.Merge(orswot2)
+	orswot2.Merge(orswot1)
+
+	expected := []string{"value1", "value2"}
+	if !reflect.DeepEqual(orswot1.Value(), expected) {
+		t.Errorf("orswot1.value should be %#v but %#v", expected, orswot1.Value())
+	}
+
+	if !orswot1.Equal(orswot2) {
+		t.Errorf("orswot1 should be same with orswot2 %#v %#v", orswot1, orswot2)
+	}
+}
+
+func TestORSWOTMergeRemoved(t *testing.T) {
+	orswot1 := NewORSWOT()
+	orswot1.Add("value1", "a")
+
+	orswot2 := NewORSWOT()
+	orswot2.Merge(orswot1)
+	if err := orswot2.Remove("value1"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+
+	orswot1.Merge(orswot2)
+	if len(orswot1.Value()) != 0 {
+		t.Errorf("observed remove should win %#v", orswot1.Value())
+	}
+}
+
+func TestORSWOTConcurrentAddWins(t *testing.T) {
+	orswot1 := NewORSWOT()
+	orswot1.Add("value1", "a")
+
+	orswot2 := NewORSWOT()
+	orswot2.Merge(orswot1)
+
+	// a removes value1 while b concurrently adds it again
+	if err := orswot1.Remove("value1"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+	orswot2.Add("value1", "b")
+
+	orswot1.Merge(orswot2)
+	orswot2.Merge(orswot1)
+
+	if !reflect.DeepEqual(orswot1.Value(), []string{"value1"}) {
+		t.Errorf("concurrent add should win %#v", orswot1.Value())
+	}
+
+	if !orswot1.Equal(orswot2) {
+		t.Errorf("orswot1 should be same with orswot2 %#v %#v", orswot1, orswot2)
+	}
+}
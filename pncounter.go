@@ -0,0 +1,105 @@
+// A PN-counter CRDT. A PN-Counter is a counter that can be incremented
+// and decremented, made of a grow-only counter for each direction.
+
+package dt
+
+import (
+	"math"
+)
+
+// A pncounter whose actors are identified by values of type A
+type PNCounterOf[A comparable] struct {
+	P GCounterOf[A]
+	N GCounterOf[A]
+}
+
+// A pncounter whose actors are identified by strings
+type PNCounter = PNCounterOf[string]
+
+// Create a new pncounter
+func NewPNCounter() PNCounter {
+	return NewPNCounterOf[string]()
+}
+
+// Create a new pncounter whose actors are identified by values of type A
+func NewPNCounterOf[A comparable]() PNCounterOf[A] {
+	return PNCounterOf[A]{P: NewGCounterOf[A](), N: NewGCounterOf[A]()}
+}
+
+// The single total value of a pncounter
+func (counter PNCounterOf[A]) Value() int {
+	return int(counter.P.Value()) - int(counter.N.Value())
+}
+
+// The single total value of a pncounter, or an error if it doesn't fit an int
+func (counter PNCounterOf[A]) ValueChecked() (int, error) {
+	p, err := counter.P.ValueChecked()
+	if err != nil {
+		return 0, err
+	}
+	n, err := counter.N.ValueChecked()
+	if err != nil {
+		return 0, err
+	}
+	if p > math.MaxInt || n > math.MaxInt {
+		return 0, ErrOverflow
+	}
+	return int(p) - int(n), nil
+}
+
+// Compare two counter for equality
+func (counter_a PNCounterOf[A]) Equal(counter_b PNCounterOf[A]) bool {
+	return counter_a.P.Equal(counter_b.P) && counter_a.N.Equal(counter_b.N)
+}
+
+// Return a copy of the counter
+func (counter PNCounterOf[A]) Clone() PNCounterOf[A] {
+	return PNCounterOf[A]{P: counter.P.Clone(), N: counter.N.Clone()}
+}
+
+// Combine all counters in the input list uinto a counter
+func (counter_a *PNCounterOf[A]) Merge(counters []PNCounterOf[A]) {
+	ps := []GCounterOf[A]{}
+	ns := []GCounterOf[A]{}
+	for _, counter_b := range counters {
+		ps = append(ps, counter_b.P)
+		ns = append(ns, counter_b.N)
+	}
+	counter_a.P.Merge(ps)
+	counter_a.N.Merge(ns)
+}
+
+// Combine the other counter into the counter
+func (counter_a *PNCounterOf[A]) MergeWith(counter_b PNCounterOf[A]) {
+	counter_a.Merge([]PNCounterOf[A]{counter_b})
+}
+
+// increment counter for the node by 1
+func (counter *PNCounterOf[A]) Increment(node A) {
+	counter.IncrementBy(node, 1)
+}
+
+// perform the increment
+func (counter *PNCounterOf[A]) IncrementBy(node A, amount uint) {
+	counter.P.IncrementBy(node, amount)
+}
+
+// decrement counter for the node by 1
+func (counter *PNCounterOf[A]) Decrement(node A) {
+	counter.DecrementBy(node, 1)
+}
+
+// perform the decrement
+func (counter *PNCounterOf[A]) DecrementBy(node A, amount uint) {
+	counter.N.IncrementBy(node, amount)
+}
+
+// perform the increment, unless it would overflow
+func (counter *PNCounterOf[A]) IncrementByChecked(node A, amount uint) error {
+	return counter.P.IncrementByChecked(node, amount)
+}
+
+// perform the decrement, unless it would overflow
+func (counter *PNCounterOf[A]) DecrementByChecked(node A, amount uint) error {
+	return counter.N.IncrementByChecked(node, amount)
+}
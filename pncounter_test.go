@@ -0,0 +1,136 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewPNCounter(t *testing.T) {
+	c1 := NewPNCounter()
+	if c1.Value() != 0 {
+		t.Errorf("Counter should be 0 but %d", c1.Value())
+	}
+}
+
+func TestPNCounterValue(t *testing.T) {
+	c1 := NewPNCounter()
+	c1.P.Counters = map[string]uint{"a": 1, "b": 13, "c": 1}
+	c1.N.Counters = map[string]uint{"a": 10, "b": 10}
+
+	if c1.Value() != -5 {
+		t.Errorf("Counter should be -5 but %d", c1.Value())
+	}
+}
+
+func TestPNCounterIncrement(t *testing.T) {
+	c1 := NewPNCounter()
+
+	c1.Increment("a")
+	c1.IncrementBy("b", 3)
+	c1.Decrement("a")
+	c1.DecrementBy("a", 2)
+
+	expected := NewPNCounter()
+	expected.P.Counters = map[string]uint{"a": 1, "b": 3}
+	expected.N.Counters = map[string]uint{"a": 3}
+
+	if !c1.Equal(expected) {
+		t.Errorf("Counter should be same with expected %#v", c1)
+	}
+
+	if c1.Value() != 1 {
+		t.Errorf("Counter should be 1 but %d", c1.Value())
+	}
+}
+
+func TestPNCounterUsage(t *testing.T) {
+	c1 := NewPNCounter()
+	c2 := NewPNCounter()
+
+	if !c1.Equal(c2) {
+		t.Errorf("C1 should be same with C2 %#v", c1)
+	}
+
+	c1.IncrementBy("a1", 2)
+	c2.Decrement("a2")
+	c1.Merge([]PNCounter{c2})
+	c2.DecrementBy("a3", 3)
+	c1.Increment("a4")
+	c1.Decrement("a1")
+	c1.Merge([]PNCounter{c2})
+
+	expected := NewPNCounter()
+	expected.P.Counters = map[string]uint{"a1": 2, "a4": 1}
+	expected.N.Counters = map[string]uint{"a1": 1, "a2": 1, "a3": 3}
+
+	if !c1.Equal(expected) {
+		t.Errorf("Counter should be same with expected %#v", c1)
+	}
+
+	if c1.Value() != -2 {
+		t.Errorf("Counter should be -2 but %d", c1.Value())
+	}
+}
+
+func TestPNCounterMultiMerge(t *testing.T) {
+	c1 := NewPNCounter()
+	c2 := NewPNCounter()
+	c3 := NewPNCounter()
+	c1.Increment("a")
+	c2.IncrementBy("b", 2)
+	c2.Decrement("a")
+	c3.DecrementBy("c", 5)
+	c1.Merge([]PNCounter{c2, c3})
+
+	if c1.Value() != -3 {
+		t.Errorf("Counter should be -3 but %d", c1.Value())
+	}
+
+	c3.MergeWith(c1)
+	if !c3.Equal(c1) {
+		t.Errorf("C3 should be same with C1 %#v", c3)
+	}
+}
+
+func TestPNCounterChecked(t *testing.T) {
+	c1 := NewPNCounter()
+
+	if err := c1.IncrementByChecked("a", math.MaxUint); err != nil {
+		t.Errorf("increment shouldn't overflow %v", err)
+	}
+
+	if err := c1.IncrementByChecked("a", 1); err != ErrOverflow {
+		t.Errorf("increment should overflow %v", err)
+	}
+
+	if err := c1.DecrementByChecked("a", 1); err != nil {
+		t.Errorf("decrement shouldn't overflow %v", err)
+	}
+
+	if _, err := c1.ValueChecked(); err != ErrOverflow {
+		t.Errorf("Counter value should overflow %v", err)
+	}
+
+	c2 := NewPNCounter()
+	c2.IncrementBy("a", 2)
+	c2.DecrementBy("b", 5)
+	if val, err := c2.ValueChecked(); val != -3 || err != nil {
+		t.Errorf("Counter should be -3 but %d %v", val, err)
+	}
+}
+
+func TestPNCounterOf(t *testing.T) {
+	c1 := NewPNCounterOf[uint64]()
+	c1.IncrementBy(1, 4)
+	c1.Decrement(2)
+	c2 := NewPNCounterOf[uint64]()
+	c2.DecrementBy(3, 2)
+
+	c1.MergeWith(c2)
+	if c1.Value() != 1 {
+		t.Errorf("Counter should be 1 but %d", c1.Value())
+	}
+}
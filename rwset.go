@@ -0,0 +1,96 @@
+// A convergent, replicated, state based remove-wins observed remove set.
+// It has the API of ORSet, but a remove wins over a concurrent add.
+
+package dt
+
+import (
+	"sort"
+)
+
+// Every add and remove gets a tag of its own. An add marks the observed
+// removes of the element as removed, and a remove marks the observed adds,
+// so only concurrent operations survive each other. The element is in
+// the set while it has live add tags and no live remove tags.
+type RWSet struct {
+	Clock   DVV
+	Adds    map[string]Tokens
+	Removes map[string]Tokens
+}
+
+// Create a new RWSet
+func NewRWSet() RWSet {
+	return RWSet{Clock: NewDVV(), Adds: map[string]Tokens{}, Removes: map[string]Tokens{}}
+}
+
+// Create a new RWSet whose version vector reads timestamps from the
+// clock. Tags are made without timestamps.
+func NewRWSetWithClock(clock Clock) RWSet {
+	rwset := NewRWSet()
+	rwset.Clock = NewDVVWithClock(clock)
+	return rwset
+}
+
+// Return values which hasn't removed only
+func (rwset RWSet) Value() []string {
+	acc := []string{}
+	for value := range rwset.Adds {
+		if rwset.exists(value) {
+			acc = append(acc, value)
+		}
+	}
+	sort.Strings(acc)
+	return acc
+}
+
+func (rwset RWSet) Lookup(elem string) bool {
+	if _, ok := rwset.Adds[elem]; ok {
+		return true
+	}
+	return false
+}
+
+// Add an element to the set with a fresh tag made by the actor,
+// superseding the removes of the element observed so far.
+func (rwset *RWSet) Add(elem string, actor string) {
+	if tokens, ok := rwset.Removes[elem]; ok {
+		tokens.removeAll()
+	}
+	addTag(rwset.Adds, elem, nextTag(&rwset.Clock, actor))
+}
+
+// Remove an element with a fresh tag made by the actor. The observed
+// adds are marked removed, and the remove tag hides the element from
+// adds made concurrently on other replicas.
+func (rwset *RWSet) Remove(elem string, actor string) error {
+	if !rwset.exists(elem) {
+		return ErrNotPresent
+	}
+	rwset.Adds[elem].removeAll()
+	addTag(rwset.Removes, elem, nextTag(&rwset.Clock, actor))
+	return nil
+}
+
+// Merge two rwset to a single rwset. Tags are unioned per element,
+// and a tag is removed if either side has removed it.
+func (rwset_a *RWSet) Merge(rwset_b RWSet) {
+	rwset_a.Clock.Merge([]DVV{rwset_b.Clock})
+	mergeTokens(rwset_a.Adds, rwset_b.Adds)
+	mergeTokens(rwset_a.Removes, rwset_b.Removes)
+}
+
+// Compare two rwset for equality
+func (rwset_a RWSet) Equal(rwset_b RWSet) bool {
+	return rwset_a.Clock.equal(rwset_b.Clock) &&
+		equalTokens(rwset_a.Adds, rwset_b.Adds) && equalTokens(rwset_a.Removes, rwset_b.Removes)
+}
+
+// Return a copy of the set
+func (rwset RWSet) Clone() RWSet {
+	return RWSet{Clock: rwset.Clock, Adds: cloneTokens(rwset.Adds), Removes: cloneTokens(rwset.Removes)}
+}
+
+// private functions
+
+func (rwset RWSet) exists(elem string) bool {
+	return rwset.Adds[elem].isAlive() && !rwset.Removes[elem].isAlive()
+}
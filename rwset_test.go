@@ -0,0 +1,125 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewRWSet(t *testing.T) {
+	rwset := NewRWSet()
+	if len(rwset.Value()) != 0 {
+		t.Errorf("rwset.value should be empty %#v", rwset)
+	}
+}
+
+func TestRWSetAddRemove(t *testing.T) {
+	rwset := NewRWSet()
+	rwset.Add("value1", "a")
+	rwset.Add("value2", "a")
+
+	if err := rwset.Remove("value1", "a"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+
+	if err := rwset.Remove("value1", "a"); err != ErrNotPresent {
+		t.Errorf("removing value1 twice should fail %v", err)
+	}
+
+	if !reflect.DeepEqual(rwset.Value(), []string{"value2"}) {
+		t.Errorf("rwset.value should be value2 %#v", rwset.Value())
+	}
+
+	rwset.Add("value1", "a")
+	if !reflect.DeepEqual(rwset.Value(), []string{"value1", "value2"}) {
+		t.Errorf("value1 should be added again %#v", rwset.Value())
+	}
+}
+
+func TestRWSetDecodedRemove(t *testing.T) {
+	rwset := NewRWSet()
+	rwset.Add("value1", "a")
+	data, err := rwset.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := RWSet{}
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Remove("value1", "b"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+	if _, ok := decoded.Removes["value1"][Dot{node: "b", counter: 1}]; !ok || len(decoded.Value()) != 0 {
+		t.Errorf("remove should be tagged by b %#v", decoded.Removes)
+	}
+}
+
+func TestRWSetConcurrentRemoveWins(t *testing.T) {
+	rwset1 := NewRWSet()
+	rwset1.Add("value1", "a")
+
+	rwset2 := NewRWSet()
+	rwset2.Merge(rwset1)
+
+	// a removes value1 while b concurrently adds it again
+	if err := rwset1.Remove("value1", "a"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+	rwset2.Add("value1", "b")
+
+	rwset1.Merge(rwset2)
+	rwset2.Merge(rwset1)
+
+	if len(rwset1.Value()) != 0 || len(rwset2.Value()) != 0 {
+		t.Errorf("concurrent remove should win %#v %#v", rwset1.Value(), rwset2.Value())
+	}
+
+	// an add which has seen the remove wins
+	rwset2.Add("value1", "b")
+	rwset1.Merge(rwset2)
+	if !reflect.DeepEqual(rwset1.Value(), []string{"value1"}) {
+		t.Errorf("later add should win %#v", rwset1.Value())
+	}
+}
+
+func TestSetSemantics(t *testing.T) {
+	// the same calls give add-wins or remove-wins semantics
+	type set interface {
+		Add(string, string)
+		Value() []string
+	}
+	run := func(set1 set, set2 set, remove func(), merge func()) []string {
+		set1.Add("value1", "a")
+		merge()
+		remove()
+		set2.Add("value1", "b")
+		merge()
+		return set1.Value()
+	}
+
+	orset1, orset2 := NewOrset(), NewOrset()
+	value := run(&orset1, &orset2, func() {
+		orset1.Remove("value1")
+	}, func() {
+		orset2.Merge(orset1)
+		orset1.Merge(orset2)
+	})
+	if !reflect.DeepEqual(value, []string{"value1"}) {
+		t.Errorf("orset should be add-wins %#v", value)
+	}
+
+	rwset1, rwset2 := NewRWSet(), NewRWSet()
+	value = run(&rwset1, &rwset2, func() {
+		rwset1.Remove("value1", "a")
+	}, func() {
+		rwset2.Merge(rwset1)
+		rwset1.Merge(rwset2)
+	})
+	if len(value) != 0 {
+		t.Errorf("rwset should be remove-wins %#v", value)
+	}
+}
@@ -0,0 +1,77 @@
+// A convergent, replicated, state based two phase set.
+// An element can be added and removed once, and never added again.
+
+package dt
+
+// A twopset of elements of type T
+type TwoPSetOf[T comparable] struct {
+	Adds    GSetOf[T]
+	Removes GSetOf[T]
+}
+
+// A twopset of strings
+type TwoPSet = TwoPSetOf[string]
+
+// Create a new twopset
+func NewTwoPSet() TwoPSet {
+	return NewTwoPSetOf[string]()
+}
+
+// Create a new twopset of elements of type T
+func NewTwoPSetOf[T comparable]() TwoPSetOf[T] {
+	return TwoPSetOf[T]{Adds: NewGSetOf[T](), Removes: NewGSetOf[T]()}
+}
+
+// Returns the elements which are added and not removed, sorted.
+func (twopset TwoPSetOf[T]) Values() []T {
+	acc := []T{}
+	for elem := range twopset.Adds.Set {
+		if !twopset.Removes.Exists(elem) {
+			acc = append(acc, elem)
+		}
+	}
+	sortValues(acc)
+	return acc
+}
+
+// return true if the element exists in the set
+func (twopset TwoPSetOf[T]) Exists(elem T) bool {
+	return twopset.Adds.Exists(elem) && !twopset.Removes.Exists(elem)
+}
+
+// append an element to the set. Adding a removed element has no effect.
+func (twopset *TwoPSetOf[T]) Add(elem T) {
+	twopset.Adds.Add(elem)
+}
+
+// remove an element from the set for good
+func (twopset *TwoPSetOf[T]) Remove(elem T) error {
+	if !twopset.Exists(elem) {
+		return ErrNotPresent
+	}
+	twopset.Removes.Add(elem)
+	return nil
+}
+
+// Combine all sets in the input list into the set
+func (twopset_a *TwoPSetOf[T]) Merge(twopsets []TwoPSetOf[T]) {
+	for _, twopset_b := range twopsets {
+		twopset_a.Adds.MergeWith(twopset_b.Adds)
+		twopset_a.Removes.MergeWith(twopset_b.Removes)
+	}
+}
+
+// Combine the other set into the set
+func (twopset_a *TwoPSetOf[T]) MergeWith(twopset_b TwoPSetOf[T]) {
+	twopset_a.Merge([]TwoPSetOf[T]{twopset_b})
+}
+
+// Compair Two set for equality
+func (twopset_a TwoPSetOf[T]) Equal(twopset_b TwoPSetOf[T]) bool {
+	return twopset_a.Adds.Equal(twopset_b.Adds) && twopset_a.Removes.Equal(twopset_b.Removes)
+}
+
+// Return a copy of the set
+func (twopset TwoPSetOf[T]) Clone() TwoPSetOf[T] {
+	return TwoPSetOf[T]{Adds: twopset.Adds.Clone(), Removes: twopset.Removes.Clone()}
+}
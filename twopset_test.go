@@ -0,0 +1,68 @@
+// SPDX-License-Idenfier: BSD-2-Clause
+// Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
+
+package dt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewTwoPSet(t *testing.T) {
+	twopset := NewTwoPSet()
+	if len(twopset.Values()) != 0 {
+		t.Errorf("twopset.value should be empty %#v", twopset)
+	}
+}
+
+func TestTwoPSetAddRemove(t *testing.T) {
+	twopset := NewTwoPSet()
+	twopset.Add("value1")
+	twopset.Add("value2")
+
+	if err := twopset.Remove("value1"); err != nil {
+		t.Errorf("value1 should be removed %v", err)
+	}
+
+	if err := twopset.Remove("value1"); err != ErrNotPresent {
+		t.Errorf("removing value1 twice should fail %v", err)
+	}
+
+	if err := twopset.Remove("value3"); err != ErrNotPresent {
+		t.Errorf("removing value3 should fail %v", err)
+	}
+
+	twopset.Add("value1")
+	if twopset.Exists("value1") {
+		t.Errorf("removed value1 shouldn't be added again")
+	}
+
+	if !reflect.DeepEqual(twopset.Values(), []string{"value2"}) {
+		t.Errorf("twopset.value should be value2 %#v", twopset.Values())
+	}
+}
+
+func TestTwoPSetMerge(t *testing.T) {
+	twopset1 := NewTwoPSet()
+	twopset1.Add("value1")
+	twopset1.Add("value2")
+
+	twopset2 := NewTwoPSet()
+	twopset2.MergeWith(twopset1)
+	twopset2.Remove("value1")
+
+	twopset3 := NewTwoPSet()
+	twopset3.Add("value3")
+
+	twopset1.Add("value1")
+	twopset1.Merge([]TwoPSet{twopset2, twopset3})
+
+	if !reflect.DeepEqual(twopset1.Values(), []string{"value2", "value3"}) {
+		t.Errorf("twopset1.value should be value2 and value3 %#v", twopset1.Values())
+	}
+
+	twopset2.Merge([]TwoPSet{twopset3, twopset1})
+	if !twopset1.Equal(twopset2) {
+		t.Errorf("twopset1 should be equal with twopset2 %#v %#v", twopset1, twopset2)
+	}
+}
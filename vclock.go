@@ -1,14 +1,15 @@
 // SPDX-License-Idenfier: BSD-2-Clause
 // Author: Eishun Kondoh <dreamdiagnosis@gmail.com>
 
-// Dotted Version Vector Sets implementation
-// based on http://haslab.uminho.pt/tome/files/dvvset-dais.pdf
+// A version vector clock based on riak_core_vclock.
+// The dotted version vector set that tracks sibling values
+// with it lives in dvvset.go.
 
 package dt
 
 import (
+	"container/heap"
 	"sort"
-	"time"
 
 	"golang.org/x/xerrors"
 )
@@ -20,9 +21,12 @@ type Dot struct {
 	timestamp uint32
 }
 
-// Individual event with timestamp
+// Individual event with timestamp. The vector is kept sorted by node
+// with at most one dot per node, which Merge and Compare rely on.
+// Timestamps are read from the clock, or the system clock if it is nil.
 type DVV struct {
 	vector []Dot
+	clock  Clock
 }
 
 // For sort. Returns length of vector of the dvv
@@ -46,17 +50,70 @@ func NewDVV() DVV {
 	return *DVV
 }
 
-//  Return true if the vclock va is a direct descendant of vclock vb, else false.
-func (va DVV) Descends(vb DVV) bool {
-	acc := true
-	for _, dot_b := range vb.vector {
-		if dot_a, err := va.GetDot(dot_b.node); err == nil {
-			acc = (dot_a.counter >= dot_b.counter) && acc
+// Instantiate a new DVV struct which reads timestamps from the clock
+func NewDVVWithClock(clock Clock) DVV {
+	return DVV{clock: clock}
+}
+
+// Causal order of a vclock relative to another vclock
+type Ordering int
+
+const (
+	Before Ordering = iota
+	After
+	Equal
+	Concurrent
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Before:
+		return "Before"
+	case After:
+		return "After"
+	case Equal:
+		return "Equal"
+	default:
+		return "Concurrent"
+	}
+}
+
+// Compare the causal order of vclock va to vclock vb in a single pass
+// over both vectors sorted by node. Note: ignores timestamps
+func (va DVV) Compare(vb DVV) Ordering {
+	dots_a := va.vector
+	dots_b := vb.vector
+	a_newer := false
+	b_newer := false
+	for len(dots_a) > 0 || len(dots_b) > 0 {
+		if len(dots_b) == 0 || (len(dots_a) > 0 && dots_a[0].node < dots_b[0].node) {
+			a_newer = a_newer || dots_a[0].counter > 0
+			dots_a = dots_a[1:]
+		} else if len(dots_a) == 0 || dots_a[0].node > dots_b[0].node {
+			b_newer = b_newer || dots_b[0].counter > 0
+			dots_b = dots_b[1:]
 		} else {
-			acc = acc && false
+			a_newer = a_newer || dots_a[0].counter > dots_b[0].counter
+			b_newer = b_newer || dots_a[0].counter < dots_b[0].counter
+			dots_a = dots_a[1:]
+			dots_b = dots_b[1:]
+		}
+		if a_newer && b_newer {
+			return Concurrent
 		}
 	}
-	return acc
+	if a_newer {
+		return After
+	} else if b_newer {
+		return Before
+	}
+	return Equal
+}
+
+// Return true if the vclock va is a direct descendant of vclock vb, else false.
+func (va DVV) Descends(vb DVV) bool {
+	order := va.Compare(vb)
+	return order == After || order == Equal
 }
 
 // true if vclock va strictly dominates vclock vb. Note: ignores timestamps
@@ -67,60 +124,49 @@ func (va DVV) Dominates(vb DVV) bool {
 	// In a same world if two vclocks descend each ether they must be equal.
 	// In riak they can descend each other and have different timestamps
 	// How? Deleted keys, re-written, then restored is one example.
-	return va.Descends(vb) && !vb.Descends(va)
+	return va.Compare(vb) == After
+}
+
+// true if both vclocks have the same counter for every node, ignoring timestamps
+func (va DVV) equal(vb DVV) bool {
+	return va.Compare(vb) == Equal
+}
+
+// Return a copy of the vclock
+func (v DVV) Clone() DVV {
+	return DVV{vector: append([]Dot(nil), v.vector...), clock: v.clock}
 }
 
 // Combine all vclocks in the input list in to their least possible common descendant.
+// The vectors are merged in a single k-way walk, taking the lowest node from a heap.
 func (v *DVV) Merge(vclocks []DVV) {
-	nclock := *v
-	for len(vclocks) > 0 {
-		vclock := vclocks[0]
-		acc := NewDVV()
-		for nclock.Len() > 0 || vclock.Len() > 0 {
-			if vclock.Len() < 1 {
-				acc.vector = append(acc.vector, nclock.vector...)
-				break
-			}
-			if nclock.Len() < 1 {
-				acc.vector = append(acc.vector, vclock.vector...)
-				break
-			} else {
-				if vclock.vector[0].node < nclock.vector[0].node {
-					acc.vector = append(acc.vector, vclock.vector[0])
-					vclock.vector = vclock.vector[1:]
-				} else if vclock.vector[0].node > nclock.vector[0].node {
-					acc.vector = append(acc.vector, nclock.vector[0])
-					nclock.vector = nclock.vector[1:]
-				} else {
-					var cnt uint32
-					var ts uint32
-					node := nclock.vector[0].node
-					if vclock.vector[0].counter > nclock.vector[0].counter {
-						cnt = vclock.vector[0].counter
-						ts = vclock.vector[0].timestamp
-					} else if vclock.vector[0].counter < nclock.vector[0].counter {
-						cnt = nclock.vector[0].counter
-						ts = nclock.vector[0].timestamp
-					} else {
-						if vclock.vector[0].timestamp < nclock.vector[0].timestamp {
-							cnt = vclock.vector[0].counter
-							ts = nclock.vector[0].timestamp
-						} else {
-							cnt = vclock.vector[0].counter
-							ts = vclock.vector[0].timestamp
-						}
-					}
-					vclock.vector = vclock.vector[1:]
-					nclock.vector = nclock.vector[1:]
-					ct := Dot{node: node, counter: cnt, timestamp: ts}
-					acc.vector = append(acc.vector, ct)
-				}
+	cursors := make(dotHeap, 0, len(vclocks)+1)
+	for _, vclock := range append([]DVV{*v}, vclocks...) {
+		if vclock.Len() > 0 {
+			cursors = append(cursors, vclock.vector)
+		}
+	}
+	heap.Init(&cursors)
+	var acc []Dot
+	for cursors.Len() > 0 {
+		dot := cursors[0][0]
+		if last := len(acc) - 1; last >= 0 && acc[last].node == dot.node {
+			if dot.counter > acc[last].counter {
+				acc[last] = dot
+			} else if dot.counter == acc[last].counter && dot.timestamp > acc[last].timestamp {
+				acc[last].timestamp = dot.timestamp
 			}
+		} else {
+			acc = append(acc, dot)
+		}
+		if len(cursors[0]) > 1 {
+			cursors[0] = cursors[0][1:]
+			heap.Fix(&cursors, 0)
+		} else {
+			heap.Pop(&cursors)
 		}
-		vclocks = vclocks[1:]
-		nclock = acc
 	}
-	v.vector = nclock.vector
+	v.vector = acc
 }
 
 // Get the counter value in a DVV set from node
@@ -143,56 +189,131 @@ func (v *DVV) GetTimestamp(id string) (uint32, error) {
 
 // Get the timestamp value in a DVV set from node
 func (v *DVV) GetDot(id string) (*Dot, error) {
-	for _, dot := range v.vector {
-		if dot.node == id {
-			return &dot, nil
-		}
+	if idx := v.search(id); idx < v.Len() && v.vector[idx].node == id {
+		dot := v.vector[idx]
+		return &dot, nil
 	}
 	return nil, xerrors.New("Not found dot of id in vector")
 }
 
 // Increment vclock at Node
 func (v *DVV) Increment(node string) {
-	ts := uint32(time.Now().Unix())
-	for idx, dot := range v.vector {
-		if dot.node == node {
-			v.vector[idx].counter = v.vector[idx].counter + 1
-			v.vector[idx].timestamp = ts
-			return
-		}
+	ts := v.now()
+	idx := v.search(node)
+	// copy the vector, it may be shared with other copies of the vclock
+	vector := make([]Dot, 0, v.Len()+1)
+	vector = append(vector, v.vector[:idx]...)
+	if idx < v.Len() && v.vector[idx].node == node {
+		dot := v.vector[idx]
+		dot.counter = dot.counter + 1
+		dot.timestamp = ts
+		vector = append(vector, dot)
+		idx = idx + 1
+	} else {
+		vector = append(vector, Dot{node: node, counter: 1, timestamp: ts})
 	}
-	dot := Dot{node: node, counter: 1, timestamp: ts}
-	v.vector = append(v.vector, dot)
+	v.vector = append(vector, v.vector[idx:]...)
 }
 
 // Possibly shrink the size of a vclock, depending on current age and size.
-func (v *DVV) Prune(now uint32, props map[string]int) {
-	// This sort need to be deterministic, to avoid spurious merge conflicts later.
-	// We achieve this by using the node ID as secondary key.
-	v.sort_by_dot()
-	for v.Len() > GetSmallVclock(props) {
-		head_time := v.vector[0].timestamp
+func (v *DVV) Prune(props map[string]int) {
+	now := v.now()
+	// The oldest dots are pruned first. This order is computed on a copy
+	// so the vclock stays sorted by node.
+	by_time := v.sortByTimestamp()
+	for len(by_time) > GetSmallVclock(props) {
+		head_time := by_time[0].timestamp
 		is_young := (now - head_time) < uint32(GetYoungVclock(props))
 		if is_young {
-			return
+			break
 		} else {
-			is_big := v.Len() > GetBigVclock(props)
+			is_big := len(by_time) > GetBigVclock(props)
 			is_old := ((now - head_time) > uint32(GetOldVclock(props)))
 			if is_big || is_old {
-				v.vector = v.vector[1:]
+				by_time = by_time[1:]
 			} else {
-				return
+				break
 			}
 		}
 	}
+	if len(by_time) == v.Len() {
+		return
+	}
+	kept := map[string]bool{}
+	for _, dot := range by_time {
+		kept[dot.node] = true
+	}
+	vector := []Dot{}
+	for _, dot := range v.vector {
+		if kept[dot.node] {
+			vector = append(vector, dot)
+		}
+	}
+	v.vector = vector
+}
+
+// Check that the vclock is sorted by node without duplicated nodes.
+// Vclocks which are not are corrupted, and merge incorrectly.
+func (v DVV) Validate() error {
+	for idx := 1; idx < v.Len(); idx++ {
+		if v.vector[idx-1].node >= v.vector[idx].node {
+			return xerrors.Errorf("vclock is not sorted by node at %q", v.vector[idx].node)
+		}
+	}
+	return nil
 }
 
 // ------------------- private functions -------------------
 
-func (v *DVV) sort_by_dot() {
-	sort.Slice(v.vector, func(i, j int) bool {
-		left := uint64(v.vector[i].counter)<<63 | uint64(v.vector[i].timestamp)
-		right := uint64(v.vector[j].counter)<<63 | uint64(v.vector[j].timestamp)
-		return left < right
+// The current time of the clock in seconds
+func (v DVV) now() uint32 {
+	return uint32(clockOrSystem(v.clock).Now().Unix())
+}
+
+// The index of the node in the vector, or where it would be inserted
+func (v DVV) search(node string) int {
+	return sort.Search(len(v.vector), func(i int) bool {
+		return v.vector[i].node >= node
+	})
+}
+
+// The remaining dots of each vclock being merged, by their lowest node
+type dotHeap [][]Dot
+
+func (h dotHeap) Len() int {
+	return len(h)
+}
+
+func (h dotHeap) Less(i, j int) bool {
+	return h[i][0].node < h[j][0].node
+}
+
+func (h dotHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *dotHeap) Push(x interface{}) {
+	*h = append(*h, x.([]Dot))
+}
+
+func (h *dotHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// The dots of the vclock ordered by timestamp. This sort need to be
+// deterministic, to avoid spurious merge conflicts later.
+// We achieve this by using the node ID as secondary key.
+func (v DVV) sortByTimestamp() []Dot {
+	dots := append([]Dot(nil), v.vector...)
+	sort.Slice(dots, func(i, j int) bool {
+		if dots[i].timestamp != dots[j].timestamp {
+			return dots[i].timestamp < dots[j].timestamp
+		}
+		return dots[i].node < dots[j].node
 	})
+	return dots
 }
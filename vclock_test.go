@@ -4,7 +4,9 @@
 package dt
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -46,10 +48,11 @@ func TestExample(t *testing.T) {
 }
 
 func TestDVVSmallPrune(t *testing.T) {
-	now_ts := uint32(time.Now().Unix())
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	now_ts := uint32(clock.Now().Unix())
 	old_ts := now_ts - 32000000
 	props := map[string]int{"small_vclock": 4}
-	vclock := NewDVV()
+	vclock := NewDVVWithClock(clock)
 	vclock.vector = []Dot{
 		Dot{node: "1",
 			counter:   1,
@@ -61,17 +64,18 @@ func TestDVVSmallPrune(t *testing.T) {
 			counter:   1,
 			timestamp: old_ts},
 	}
-	vclock.Prune(now_ts, props)
+	vclock.Prune(props)
 	if !(vclock.Len() == 3) {
 		t.Error("vclock with less entries small_vclocks will be untouched")
 	}
 }
 
 func TestDVVYoungPrune(t *testing.T) {
-	now_ts := uint32(time.Now().Unix())
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	now_ts := uint32(clock.Now().Unix())
 	new_ts := now_ts - 1
 	props := map[string]int{"small_vclock": 1, "young_vclock": 1000}
-	vclock := NewDVV()
+	vclock := NewDVVWithClock(clock)
 	vclock.vector = []Dot{
 		Dot{node: "1",
 			counter:   1,
@@ -83,7 +87,7 @@ func TestDVVYoungPrune(t *testing.T) {
 			counter:   1,
 			timestamp: new_ts},
 	}
-	vclock.Prune(now_ts, props)
+	vclock.Prune(props)
 	if !(vclock.Len() == 3) {
 		t.Error("vclock with less entries small_vclocks will be untouched")
 	}
@@ -92,7 +96,8 @@ func TestDVVYoungPrune(t *testing.T) {
 func TestDVVBigPrune(t *testing.T) {
 	// vclock not preserved by small or young will be pruned down to
 	// no larger than big_vclock entries
-	now_ts := uint32(time.Now().Unix())
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	now_ts := uint32(clock.Now().Unix())
 	new_ts := now_ts - 1000
 	props := map[string]int{
 		"small_vclock": 1,
@@ -100,7 +105,7 @@ func TestDVVBigPrune(t *testing.T) {
 		"big_vclock":   2,
 		"old_vclock":   100000,
 	}
-	vclock := NewDVV()
+	vclock := NewDVVWithClock(clock)
 	vclock.vector = []Dot{
 		Dot{node: "1",
 			counter:   1,
@@ -112,7 +117,7 @@ func TestDVVBigPrune(t *testing.T) {
 			counter:   1,
 			timestamp: new_ts},
 	}
-	vclock.Prune(now_ts, props)
+	vclock.Prune(props)
 	if !(vclock.Len() == 2) {
 		t.Error("vclock should be 2")
 	}
@@ -121,7 +126,8 @@ func TestDVVBigPrune(t *testing.T) {
 func TestDVVOldPrune(t *testing.T) {
 	// vclock not preserved by small or young will be pruned down to
 	// no larger than big_vclock and no entries more than old_vclock ago
-	now_ts := uint32(time.Now().Unix())
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	now_ts := uint32(clock.Now().Unix())
 	new_ts := now_ts - 1000
 	old_ts := now_ts - 100000
 	props := map[string]int{
@@ -130,7 +136,7 @@ func TestDVVOldPrune(t *testing.T) {
 		"big_vclock":   2,
 		"old_vclock":   10000,
 	}
-	vclock := NewDVV()
+	vclock := NewDVVWithClock(clock)
 	vclock.vector = []Dot{
 		Dot{node: "1",
 			counter:   1,
@@ -142,7 +148,7 @@ func TestDVVOldPrune(t *testing.T) {
 			counter:   1,
 			timestamp: old_ts},
 	}
-	vclock.Prune(now_ts, props)
+	vclock.Prune(props)
 	if !(vclock.Len() == 1) {
 		t.Error("vclock should be 1")
 	}
@@ -151,7 +157,8 @@ func TestDVVOldPrune(t *testing.T) {
 func TestDVVPruneOrder(t *testing.T) {
 	// vclock with two nodes of the same timestamp will be pruned down
 	// to the same node
-	now_ts := uint32(time.Now().Unix())
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	now_ts := uint32(clock.Now().Unix())
 	old_ts := now_ts - 100000
 	props := map[string]int{
 		"small_vclock": 1,
@@ -159,7 +166,7 @@ func TestDVVPruneOrder(t *testing.T) {
 		"big_vclock":   2,
 		"old_vclock":   10000,
 	}
-	vclock1 := NewDVV()
+	vclock1 := NewDVVWithClock(clock)
 	vclock1.vector = []Dot{
 		Dot{node: "1",
 			counter:   1,
@@ -169,7 +176,7 @@ func TestDVVPruneOrder(t *testing.T) {
 			timestamp: old_ts},
 	}
 
-	vclock2 := NewDVV()
+	vclock2 := NewDVVWithClock(clock)
 	vclock2.vector = []Dot{
 		Dot{node: "2",
 			counter:   2,
@@ -179,8 +186,8 @@ func TestDVVPruneOrder(t *testing.T) {
 			timestamp: old_ts},
 	}
 
-	vclock1.Prune(now_ts, props)
-	vclock2.Prune(now_ts, props)
+	vclock1.Prune(props)
+	vclock2.Prune(props)
 	if !(vclock1.vector[0] == vclock2.vector[0]) {
 		t.Error("vclock should be same")
 	}
@@ -393,3 +400,216 @@ func TestDVVGetEntry(t *testing.T) {
 		t.Error("should be 1")
 	}
 }
+
+func TestDVVCompare(t *testing.T) {
+	a := NewDVV()
+	a.Increment("a")
+	b := NewDVV()
+	b.Merge([]DVV{a})
+	b.Increment("b")
+	c := NewDVV()
+	c.Merge([]DVV{a})
+	c.Increment("c")
+	// same counters as a, with another timestamp
+	d := NewDVV()
+	d.vector = []Dot{Dot{node: "a", counter: 1, timestamp: 0}}
+
+	cases := []struct {
+		va       DVV
+		vb       DVV
+		expected Ordering
+	}{
+		{a, a, Equal},
+		{a, d, Equal},
+		{a, b, Before},
+		{b, a, After},
+		{b, c, Concurrent},
+		{c, b, Concurrent},
+		{NewDVV(), a, Before},
+		{a, NewDVV(), After},
+		{NewDVV(), NewDVV(), Equal},
+	}
+	for i, c := range cases {
+		if order := c.va.Compare(c.vb); order != c.expected {
+			t.Errorf("case %d: order should be %s but %s", i, c.expected, order)
+		}
+	}
+
+	if !b.Dominates(a) || a.Dominates(b) || a.Dominates(d) || b.Dominates(c) {
+		t.Error("only descendants with greater counters should dominate")
+	}
+
+	if !b.Descends(a) || !a.Descends(d) || a.Descends(b) || b.Descends(c) {
+		t.Error("descendants and equal vclocks should descend")
+	}
+}
+
+func TestDVVSortedByNode(t *testing.T) {
+	a := NewDVV()
+	a.Increment("c")
+	a.Increment("a")
+	a.Increment("b")
+	a.Increment("a")
+	if err := a.Validate(); err != nil {
+		t.Errorf("incremented vclock should be sorted %v", err)
+	}
+
+	b := NewDVV()
+	b.Increment("d")
+	b.Increment("a")
+	a.Merge([]DVV{b})
+	if err := a.Validate(); err != nil || a.Len() != 4 {
+		t.Errorf("merged vclock should be sorted without duplicates %#v", a)
+	}
+
+	clock := NewManualClock(time.Unix(1600000000, 0))
+	now_ts := uint32(clock.Now().Unix())
+	props := map[string]int{
+		"small_vclock": 1,
+		"young_vclock": 1,
+		"big_vclock":   2,
+		"old_vclock":   10000,
+	}
+	c := NewDVVWithClock(clock)
+	c.vector = []Dot{
+		Dot{node: "1", counter: 1, timestamp: now_ts - 100},
+		Dot{node: "2", counter: 1, timestamp: now_ts - 300},
+		Dot{node: "3", counter: 1, timestamp: now_ts - 200},
+	}
+	c.Prune(props)
+	expect := []Dot{
+		Dot{node: "1", counter: 1, timestamp: now_ts - 100},
+		Dot{node: "3", counter: 1, timestamp: now_ts - 200},
+	}
+	if !reflect.DeepEqual(c.vector, expect) {
+		t.Errorf("pruned vclock should be sorted by node %#v", c)
+	}
+}
+
+func TestDVVIncrementCopy(t *testing.T) {
+	a := NewDVV()
+	a.Increment("a")
+	b := a
+	b.Increment("a")
+	if a.GetCounter("a") != 1 || b.GetCounter("a") != 2 {
+		t.Error("incrementing a copy shouldn't change the vclock")
+	}
+}
+
+func TestDVVValidate(t *testing.T) {
+	vclock := NewDVV()
+	vclock.vector = []Dot{
+		Dot{node: "2", counter: 1, timestamp: 1},
+		Dot{node: "1", counter: 1, timestamp: 1},
+	}
+	if vclock.Validate() == nil {
+		t.Error("unsorted vclock should be invalid")
+	}
+
+	vclock.vector = []Dot{
+		Dot{node: "1", counter: 1, timestamp: 1},
+		Dot{node: "1", counter: 2, timestamp: 1},
+	}
+	if vclock.Validate() == nil {
+		t.Error("vclock with duplicated nodes should be invalid")
+	}
+
+	if NewDVV().Validate() != nil {
+		t.Error("empty vclock should be valid")
+	}
+}
+
+func TestDVVMultiMerge(t *testing.T) {
+	vc1 := NewDVV()
+	vc1.vector = []Dot{
+		Dot{node: "1", counter: 1, timestamp: 1},
+		Dot{node: "3", counter: 3, timestamp: 3},
+	}
+	vc2 := NewDVV()
+	vc2.vector = []Dot{
+		Dot{node: "2", counter: 2, timestamp: 2},
+		Dot{node: "3", counter: 5, timestamp: 1},
+	}
+	vc3 := NewDVV()
+	vc3.vector = []Dot{
+		Dot{node: "1", counter: 1, timestamp: 4},
+		Dot{node: "4", counter: 4, timestamp: 4},
+	}
+	vc1.Merge([]DVV{vc2, NewDVV(), vc3})
+
+	expect := []Dot{
+		Dot{node: "1", counter: 1, timestamp: 4},
+		Dot{node: "2", counter: 2, timestamp: 2},
+		Dot{node: "3", counter: 5, timestamp: 1},
+		Dot{node: "4", counter: 4, timestamp: 4},
+	}
+	if !reflect.DeepEqual(vc1.vector, expect) {
+		t.Errorf("vc1 should be merged as expected array %#v", vc1)
+	}
+
+	empty := NewDVV()
+	empty.Merge([]DVV{NewDVV()})
+	if !reflect.DeepEqual(empty, NewDVV()) {
+		t.Errorf("merged empty vclocks should be empty %#v", empty)
+	}
+}
+
+func newBenchDVV(actors int, offset uint32) DVV {
+	vclock := NewDVV()
+	for i := 0; i < actors; i++ {
+		node := fmt.Sprintf("actor%04d", i)
+		vclock.vector = append(vclock.vector, Dot{node: node, counter: uint32(i) + offset, timestamp: 1})
+	}
+	return vclock
+}
+
+func BenchmarkDVVGetDot(b *testing.B) {
+	for _, actors := range []int{10, 100, 1000} {
+		vclock := newBenchDVV(actors, 1)
+		node := fmt.Sprintf("actor%04d", actors/2)
+		b.Run(strconv.Itoa(actors), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				vclock.GetDot(node)
+			}
+		})
+	}
+}
+
+func BenchmarkDVVDescends(b *testing.B) {
+	for _, actors := range []int{10, 100, 1000} {
+		va := newBenchDVV(actors, 2)
+		vb := newBenchDVV(actors, 1)
+		b.Run(strconv.Itoa(actors), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				va.Descends(vb)
+			}
+		})
+	}
+}
+
+func BenchmarkDVVMerge(b *testing.B) {
+	for _, actors := range []int{10, 100, 1000} {
+		vclocks := []DVV{}
+		for i := 0; i < 10; i++ {
+			vclocks = append(vclocks, newBenchDVV(actors, uint32(i)))
+		}
+		b.Run(strconv.Itoa(actors), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				vclock := NewDVV()
+				vclock.Merge(vclocks)
+			}
+		})
+	}
+}
+
+func BenchmarkDVVIncrement(b *testing.B) {
+	for _, actors := range []int{10, 100, 1000} {
+		vclock := newBenchDVV(actors, 1)
+		node := fmt.Sprintf("actor%04d", actors/2)
+		b.Run(strconv.Itoa(actors), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				vclock.Increment(node)
+			}
+		})
+	}
+}